@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	"goSSH/internal/config"
+	"goSSH/internal/secret"
+	"goSSH/internal/ssh"
 	"goSSH/models"
 )
 
@@ -89,21 +93,168 @@ var addCmd = &cobra.Command{
 		}
 
 		prompt = promptui.Prompt{
-			Label: "密码",
-			Mask:  '*',
+			Label: "私钥文件路径 (可选，留空则使用密码认证)",
+			Validate: func(input string) error {
+				if input == "" {
+					return nil
+				}
+				path, err := ssh.ExpandPath(input)
+				if err != nil {
+					return err
+				}
+				if _, err := os.Stat(path); err != nil {
+					return fmt.Errorf("私钥文件不存在")
+				}
+				return nil
+			},
 		}
-		password, err := prompt.Run()
+		identityFile, err := prompt.Run()
+		if err != nil {
+			fmt.Printf("输入取消: %v\n", err)
+			return
+		}
+
+		var passphrase string
+		if identityFile != "" {
+			prompt = promptui.Prompt{
+				Label: "私钥口令 (可选，留空则在连接时询问)",
+				Mask:  '*',
+			}
+			passphrase, err = prompt.Run()
+			if err != nil {
+				fmt.Printf("输入取消: %v\n", err)
+				return
+			}
+		}
+
+		prompt = promptui.Prompt{
+			Label: "密码引用 (可选，如 env:PROD_PW、keyring:gossh/prod、cmd:pass show prod，留空则直接输入密码)",
+			Validate: func(input string) error {
+				if input == "" {
+					return nil
+				}
+				return secret.Validate(input)
+			},
+		}
+		passwordRef, err := prompt.Run()
+		if err != nil {
+			fmt.Printf("输入取消: %v\n", err)
+			return
+		}
+
+		var password string
+		if passwordRef == "" {
+			passwordLabel := "密码"
+			if identityFile != "" {
+				passwordLabel = "密码 (可选，私钥认证失败时使用)"
+			}
+			prompt = promptui.Prompt{
+				Label: passwordLabel,
+				Mask:  '*',
+			}
+			password, err = prompt.Run()
+			if err != nil {
+				fmt.Printf("输入取消: %v\n", err)
+				return
+			}
+		}
+
+		prompt = promptui.Prompt{
+			Label:     "是否转发本地 ssh-agent 到远程服务器",
+			IsConfirm: true,
+		}
+		_, err = prompt.Run()
+		if err == promptui.ErrInterrupt {
+			fmt.Printf("输入取消: %v\n", err)
+			return
+		}
+		forwardAgent := err == nil
+
+		prompt = promptui.Prompt{
+			Label: "跳板机 (可选，已配置的服务器名称，多个用逗号分隔，按连接顺序)",
+			Validate: func(input string) error {
+				for _, jump := range splitList(input) {
+					if jump == name {
+						return fmt.Errorf("不能将自身设置为跳板机")
+					}
+					if _, err := manager.GetServer(jump); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+		jumpInput, err := prompt.Run()
+		if err != nil {
+			fmt.Printf("输入取消: %v\n", err)
+			return
+		}
+
+		prompt = promptui.Prompt{
+			Label: "出站代理 (可选，如 socks5://proxy:1080、http://user@proxy:3128，none 表示不使用全局代理)",
+			Validate: func(input string) error {
+				if input == "" || input == ssh.ProxyNone {
+					return nil
+				}
+				_, err := ssh.ParseProxy(input)
+				return err
+			},
+		}
+		proxyURL, err := prompt.Run()
+		if err != nil {
+			fmt.Printf("输入取消: %v\n", err)
+			return
+		}
+
+		// 代理地址中包含用户名但没有密码时，询问代理密码
+		var proxyPassword string
+		if proxyURL != "" && proxyURL != ssh.ProxyNone {
+			u, _ := ssh.ParseProxy(proxyURL)
+			_, hasPassword := u.User.Password()
+			if u.User != nil && !hasPassword {
+				prompt = promptui.Prompt{
+					Label: "代理密码",
+					Mask:  '*',
+				}
+				proxyPassword, err = prompt.Run()
+				if err != nil {
+					fmt.Printf("输入取消: %v\n", err)
+					return
+				}
+			}
+		}
+
+		prompt = promptui.Prompt{
+			Label: "标签 (可选，多个用逗号分隔，如 web,env=prod)",
+			Validate: func(input string) error {
+				for _, tag := range splitList(input) {
+					if err := config.ValidateTag(tag); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+		tagInput, err := prompt.Run()
 		if err != nil {
 			fmt.Printf("输入取消: %v\n", err)
 			return
 		}
 
 		server := models.Server{
-			Name:     name,
-			Host:     host,
-			Port:     port,
-			Username: username,
-			Password: password,
+			Name:          name,
+			Tags:          splitList(tagInput),
+			Host:          host,
+			Port:          port,
+			Username:      username,
+			Password:      password,
+			PasswordRef:   passwordRef,
+			IdentityFile:  identityFile,
+			Passphrase:    passphrase,
+			ForwardAgent:  forwardAgent,
+			Jump:          splitList(jumpInput),
+			Proxy:         proxyURL,
+			ProxyPassword: proxyPassword,
 		}
 
 		if err := manager.AddServer(server); err != nil {
@@ -115,6 +266,17 @@ var addCmd = &cobra.Command{
 	},
 }
 
+// splitList 解析逗号分隔的列表（跳板机、标签等），忽略空项
+func splitList(input string) []string {
+	var items []string
+	for _, item := range strings.Split(input, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func init() {
 	rootCmd.AddCommand(addCmd)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	"goSSH/internal/config"
@@ -11,7 +12,8 @@ import (
 )
 
 var (
-	noNewTab bool // --no-new-tab 标志，避免在新标签页中递归打开新标签页
+	noNewTab       bool // --no-new-tab 标志，避免在新标签页中递归打开新标签页
+	connectVerbose bool // -v 标志，显示协商的算法
 )
 
 var connectCmd = &cobra.Command{
@@ -62,7 +64,7 @@ var connectCmd = &cobra.Command{
 		}
 
 		// 获取服务器配置
-		server, err := manager.GetServer(serverName)
+		server, err := resolveServer(manager, serverName, "选择要连接的服务器")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 			return
@@ -72,6 +74,11 @@ var connectCmd = &cobra.Command{
 		client := ssh.NewClient(server)
 		defer client.Close()
 
+		// 显示协商的算法时需要直接连接服务器，不经过主连接进程
+		if connectVerbose {
+			client.DisableMaster()
+		}
+
 		// 连接服务器
 		fmt.Printf("正在连接到 %s (%s:%d)...\n", server.Name, server.Host, server.Port)
 		if err := client.Connect(); err != nil {
@@ -81,6 +88,10 @@ var connectCmd = &cobra.Command{
 
 		fmt.Printf("✓ 已连接到 %s\n\n", server.Name)
 
+		if connectVerbose {
+			printAlgorithms(client)
+		}
+
 		// 启动交互式Shell
 		// 如果设置了 --no-new-tab 标志，则直接在当前终端执行，不尝试新标签页
 		executor := ssh.NewExecutor(client)
@@ -91,8 +102,34 @@ var connectCmd = &cobra.Command{
 	},
 }
 
+// printAlgorithms 输出与服务器协商的算法，存在安全问题的算法会特别标出
+func printAlgorithms(client *ssh.Client) {
+	algos, ok := client.Algorithms()
+	if !ok {
+		return
+	}
+
+	describe := func(name string) string {
+		if name == "" {
+			return "-"
+		}
+		if ssh.IsInsecureAlgorithm(name) {
+			return name + color.New(color.FgYellow).Sprint(" (不安全)")
+		}
+		return name
+	}
+
+	fmt.Println("协商的算法:")
+	fmt.Printf("  密钥交换: %s\n", describe(algos.KeyExchange))
+	fmt.Printf("  主机密钥: %s\n", describe(algos.HostKey))
+	fmt.Printf("  加密算法: %s（发送） / %s（接收）\n", describe(algos.Write.Cipher), describe(algos.Read.Cipher))
+	fmt.Printf("  MAC 算法: %s（发送） / %s（接收）\n", describe(algos.Write.MAC), describe(algos.Read.MAC))
+	fmt.Println()
+}
+
 func init() {
 	connectCmd.Flags().BoolVar(&noNewTab, "no-new-tab", false, "在当前终端中执行，不尝试在新标签页中打开（避免递归）")
+	connectCmd.Flags().BoolVarP(&connectVerbose, "verbose", "v", false, "显示与服务器协商的密钥交换、主机密钥、加密和 MAC 算法")
 	rootCmd.AddCommand(connectCmd)
 }
 
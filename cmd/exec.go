@@ -1,26 +1,61 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	"goSSH/internal/config"
 	"goSSH/internal/ssh"
+	"goSSH/models"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+var (
+	execParallel int           // --parallel 标志，多服务器执行时的最大并发数
+	execOutput   string        // --output 标志，输出格式（text、json、ndjson）
+	execTimeout  time.Duration // --timeout 标志，每个服务器上命令的最长执行时间
+	execStdin    bool          // --stdin-broadcast 标志，将本地标准输入发送给所有服务器
 )
 
 var execCmd = &cobra.Command{
 	Use:   "exec [name] [command]",
 	Short: "在远程服务器上执行命令",
-	Long:  "在指定的远程服务器上执行命令，如果未提供名称则交互式选择",
-	Args:  cobra.MinimumNArgs(0),
+	Long: `在指定的远程服务器上执行命令，如果未提供名称则交互式选择。
+名称为选择器（如 @web,env=prod）时在所有匹配的服务器上并发执行，每行输出前带有 [服务器名称] 前缀，
+结束后显示每个服务器的退出码和耗时，任意服务器执行失败时以非零状态退出。
+
+使用 --output json 或 --output ndjson 时不直接输出命令的结果，而是为每个服务器输出一条记录，
+包含标准输出、错误输出、退出码、终止信号、开始和结束时间以及连接错误。
+json 在所有服务器执行结束后输出一个数组，ndjson 在每个服务器执行结束后立即输出一行。
+
+执行过程中按 Ctrl+C（以及收到 SIGTERM、SIGHUP）时会将信号转发给远程命令，再次按 Ctrl+C 直接断开。
+使用 --timeout 限制命令的执行时间，超时后向远程命令发送 TERM 信号，5 秒后仍未结束则断开，退出码为 124。
+
+标准输入不是终端时（如 cat dump.sql | goss exec db psql），会将其发送给远程命令，读完后关闭远程命令的标准输入。
+在多个服务器上执行时，使用 --stdin-broadcast 将同样的输入发送给每个服务器。`,
+	Args: cobra.MinimumNArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
+		if execOutput != "text" && execOutput != "json" && execOutput != "ndjson" {
+			fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 '%s'，可选值: text, json, ndjson\n", execOutput)
+			os.Exit(1)
+		}
+
 		manager, err := config.NewManager()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-			return
+			os.Exit(1)
 		}
 
 		var serverName string
@@ -33,7 +68,8 @@ var execCmd = &cobra.Command{
 			serverName = args[0]
 			// 交互式输入命令
 			prompt := promptui.Prompt{
-				Label: "要执行的命令",
+				Label:  "要执行的命令",
+				Stdout: os.Stderr,
 				Validate: func(input string) error {
 					if input == "" {
 						return fmt.Errorf("命令不能为空")
@@ -44,7 +80,7 @@ var execCmd = &cobra.Command{
 			var err error
 			command, err = prompt.Run()
 			if err != nil {
-				fmt.Printf("输入取消: %v\n", err)
+				fmt.Fprintf(os.Stderr, "输入取消: %v\n", err)
 				return
 			}
 		} else {
@@ -56,7 +92,7 @@ var execCmd = &cobra.Command{
 			}
 
 			if len(servers) == 0 {
-				fmt.Println("没有配置任何服务器，请先使用 'goss add' 添加服务器")
+				fmt.Fprintln(os.Stderr, "没有配置任何服务器，请先使用 'goss add' 添加服务器")
 				return
 			}
 
@@ -66,20 +102,22 @@ var execCmd = &cobra.Command{
 			}
 
 			prompt := promptui.Select{
-				Label: "选择服务器",
-				Items: items,
+				Label:  "选择服务器",
+				Items:  items,
+				Stdout: os.Stderr,
 			}
 
 			index, _, err := prompt.Run()
 			if err != nil {
-				fmt.Printf("操作取消: %v\n", err)
+				fmt.Fprintf(os.Stderr, "操作取消: %v\n", err)
 				return
 			}
 
 			serverName = servers[index].Name
 
 			prompt2 := promptui.Prompt{
-				Label: "要执行的命令",
+				Label:  "要执行的命令",
+				Stdout: os.Stderr,
 				Validate: func(input string) error {
 					if input == "" {
 						return fmt.Errorf("命令不能为空")
@@ -89,33 +127,239 @@ var execCmd = &cobra.Command{
 			}
 			command, err = prompt2.Run()
 			if err != nil {
-				fmt.Printf("输入取消: %v\n", err)
+				fmt.Fprintf(os.Stderr, "输入取消: %v\n", err)
 				return
 			}
 		}
 
+		// 选择器在所有匹配的服务器上执行
+		if config.IsSelector(serverName) {
+			servers, err := manager.Select(serverName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			run := runParallelExec
+			if execOutput != "text" {
+				run = runStructuredExec
+			}
+			os.Exit(run(servers, command))
+		}
+
 		// 获取服务器配置
-		server, err := manager.GetServer(serverName)
+		server, err := resolveServer(manager, serverName, "选择服务器")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-			return
+			os.Exit(1)
+		}
+
+		if execOutput != "text" {
+			os.Exit(runStructuredExec([]models.Server{*server}, command))
+		}
+
+		var stdin io.Reader
+		if execStdin || !isTerminal() {
+			stdin = os.Stdin
 		}
 
 		// 创建SSH客户端和执行器
 		client := ssh.NewClient(server)
-		defer client.Close()
-
 		executor := ssh.NewExecutor(client)
 
-		// 执行命令（流式输出）
-		if err := executor.ExecuteWithStream(command); err != nil {
+		ctx, cancel := execContext()
+		relay := ssh.NewSignalRelay()
+
+		// 执行命令（流式输出），以远程命令的退出码退出
+		err = executor.ExecuteContext(ctx, command, ssh.ExecOptions{Stdin: stdin, Stdout: os.Stdout, Stderr: os.Stderr, Relay: relay})
+		relay.Stop()
+		cancel()
+		client.Close()
+
+		var exitErr *cryptossh.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
 			fmt.Fprintf(os.Stderr, "执行失败: %v\n", err)
-			os.Exit(1)
 		}
+		os.Exit(ssh.ExitCode(err))
 	},
 }
 
+// execContext 返回执行命令使用的 context，设置了 --timeout 时带有截止时间
+func execContext() (context.Context, context.CancelFunc) {
+	if execTimeout > 0 {
+		return context.WithTimeout(context.Background(), execTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// execOptions 返回通过 ExecuteParallel 和 CollectParallel 执行的选项
+// 使用 --stdin-broadcast 或只有一个服务器且标准输入不是终端时，将标准输入发送给每个服务器
+func execOptions(relay *ssh.SignalRelay, servers []models.Server) ssh.ParallelOptions {
+	opts := ssh.ParallelOptions{Parallel: execParallel, Timeout: execTimeout, Relay: relay}
+	if execStdin || (len(servers) == 1 && !isTerminal()) {
+		opts.Stdin = os.Stdin
+	}
+	return opts
+}
+
+// hostColors 多服务器执行时用于区分服务器输出前缀的颜色
+var hostColors = []color.Attribute{
+	color.FgCyan, color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta,
+	color.FgHiCyan, color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta,
+}
+
+// runParallelExec 在多个服务器上并发执行命令，输出带服务器前缀的结果和汇总表，返回进程退出码
+func runParallelExec(servers []models.Server, command string) int {
+	width := 0
+	for _, server := range servers {
+		width = max(width, len(server.Name))
+	}
+
+	// 同一服务器的标准输出和错误输出共用前缀，所有服务器共用一把锁，保证输出按行交错
+	var mu sync.Mutex
+	writers := make(map[string][2]*prefixWriter, len(servers))
+	for i, server := range servers {
+		prefix := color.New(hostColors[i%len(hostColors)]).Sprintf("[%-*s]", width, server.Name) + " "
+		writers[server.Name] = [2]*prefixWriter{
+			{out: os.Stdout, prefix: prefix, mu: &mu},
+			{out: os.Stderr, prefix: prefix, mu: &mu},
+		}
+	}
+
+	relay := ssh.NewSignalRelay()
+	defer relay.Stop()
+
+	results := ssh.ExecuteParallel(servers, command, execOptions(relay, servers), func(server *models.Server) (io.Writer, io.Writer) {
+		w := writers[server.Name]
+		return w[0], w[1]
+	})
+	for _, w := range writers {
+		w[0].Flush()
+		w[1].Flush()
+	}
+
+	printExecSummary(results)
+
+	errs := make([]error, len(results))
+	for i, r := range results {
+		errs[i] = r.Err
+	}
+	return execExitCode(errs)
+}
+
+// runStructuredExec 在服务器上执行命令，按 --output 指定的格式输出每个服务器的结构化记录，返回进程退出码
+func runStructuredExec(servers []models.Server, command string) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+
+	var done func(ssh.ExecRecord)
+	if execOutput == "ndjson" {
+		done = func(record ssh.ExecRecord) {
+			encoder.Encode(record)
+		}
+	}
+	relay := ssh.NewSignalRelay()
+	defer relay.Stop()
+
+	records := ssh.CollectParallel(servers, command, execOptions(relay, servers), done)
+
+	if execOutput == "json" {
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+	}
+
+	errs := make([]error, len(records))
+	for i, record := range records {
+		errs[i] = record.Err
+	}
+	return execExitCode(errs)
+}
+
+// execExitCode 返回在多个服务器上执行命令后的进程退出码
+// 全部成功时为 0，否则为失败的服务器中最大的退出码（远程命令的退出码或连接、认证、主机密钥失败的保留退出码）
+func execExitCode(errs []error) int {
+	code := 0
+	for _, err := range errs {
+		code = max(code, ssh.ExitCode(err))
+	}
+	return code
+}
+
+// printExecSummary 输出多服务器执行的汇总表（退出码、耗时、错误）
+func printExecSummary(results []ssh.HostResult) {
+	headerColor := color.New(color.FgCyan, color.Bold)
+	successColor := color.New(color.FgGreen)
+	errColor := color.New(color.FgRed)
+
+	headerColor.Fprintf(os.Stderr, "\n%-20s %-8s %-8s %-10s %s\n", "名称", "结果", "退出码", "耗时", "错误")
+	fmt.Fprintln(os.Stderr, "──────────────────────────────────────────────────────────────────────")
+
+	failed := 0
+	for _, r := range results {
+		status, exitCode, message := successColor.Sprintf("%-8s", "✓ 成功"), strconv.Itoa(r.ExitCode), ""
+		if r.Err != nil {
+			failed++
+			status, message = errColor.Sprintf("%-8s", "✗ 失败"), r.Err.Error()
+		}
+		// 退出码已单独显示，只有被信号终止时才需要说明
+		if r.ExitCode >= 0 {
+			message = ""
+			if r.Signal != "" {
+				message = fmt.Sprintf("被信号 %s 终止", r.Signal)
+			}
+		} else {
+			exitCode = "-"
+		}
+		fmt.Fprintf(os.Stderr, "%-20s %s %-8s %-10s %s\n", r.Server.Name, status, exitCode, r.Duration.Round(10*time.Millisecond), message)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n共 %d 个服务器，成功 %d 个，失败 %d 个\n", len(results), len(results)-failed, failed)
+}
+
+// prefixWriter 在每行输出前加上前缀，完整的一行才会写出，避免多个服务器的输出在行内混杂
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+// Write 缓存不完整的行，写出所有完整的行
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 写出最后一行没有换行符的输出
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+// writeLine 加锁写出带前缀的一行
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	io.WriteString(w.out, w.prefix)
+	w.out.Write(line)
+}
+
 func init() {
+	execCmd.Flags().IntVarP(&execParallel, "parallel", "p", ssh.DefaultParallel, "多服务器执行时最多同时执行的服务器数量（0 表示不限制）")
+	execCmd.Flags().StringVarP(&execOutput, "output", "o", "text", "输出格式: text, json, ndjson")
+	execCmd.Flags().BoolVar(&execStdin, "stdin-broadcast", false, "将标准输入发送给每个服务器上的命令（多服务器执行时）")
+	execCmd.Flags().DurationVar(&execTimeout, "timeout", 0, "每个服务器上命令的最长执行时间（如 30s、5m），0 表示不限制")
 	rootCmd.AddCommand(execCmd)
 }
-
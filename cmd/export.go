@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"goSSH/internal/config"
+	"goSSH/internal/ssh"
+	"goSSH/internal/storage"
+	"goSSH/models"
+)
+
+var (
+	exportFormat         string // --format 标志，导出格式
+	exportOutput         string // --output 标志，输出文件
+	exportIncludeSecrets bool   // --include-secrets 标志，导出密码等敏感字段
+)
+
+// exportFormats 支持的导出格式及对应的生成函数
+var exportFormats = map[string]func(w io.Writer, servers []models.Server) error{
+	"ssh-config":   exportSSHConfig,
+	"ansible-ini":  exportAnsibleINI,
+	"ansible-yaml": exportAnsibleYAML,
+	"csv":          exportCSV,
+	"json":         exportJSON,
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [name]...",
+	Short: "导出服务器配置供其他工具使用",
+	Long: `将服务器配置导出为其他工具可以使用的格式，未提供名称时导出所有服务器。
+支持的格式: ssh-config（OpenSSH 客户端配置）、ansible-ini、ansible-yaml（Ansible 清单，标签导出为主机组）、csv 和 json。
+默认不导出密码、私钥口令等敏感字段，使用 --include-secrets 导出（ssh-config 格式不包含密码）。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		generate, ok := exportFormats[exportFormat]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "错误: 不支持的导出格式 '%s'，可用: ssh-config、ansible-ini、ansible-yaml、csv、json\n", exportFormat)
+			os.Exit(1)
+		}
+
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		// json 与配置文件的结构相同，导出保存的原始配置（不合并全局设置），以便放回配置文件中
+		list := manager.ListServers
+		if exportFormat == "json" {
+			list = manager.StoredServers
+		}
+		servers, err := list()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(args) > 0 {
+			names, err := expandSelectors(args)
+			if err == nil {
+				servers, err = selectWithJumps(servers, names)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if !exportIncludeSecrets {
+			for i := range servers {
+				redactServer(&servers[i])
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := generate(&buf, servers); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		if exportOutput == "" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+
+		// 包含敏感字段时只允许当前用户读取
+		perm := os.FileMode(0644)
+		if exportIncludeSecrets {
+			perm = 0600
+		}
+		if err := writeExportFile(exportOutput, buf.Bytes(), perm); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 写入 %s 失败: %v\n", exportOutput, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ 已导出 %d 个服务器到 %s\n", len(servers), exportOutput)
+	},
+}
+
+// writeExportFile 写入导出文件，已存在的文件权限比 perm 宽松时先收紧再写入
+func writeExportFile(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	// OpenFile 不会修改已存在文件的权限
+	info, err := file.Stat()
+	if err == nil && info.Mode().Perm()&^perm != 0 {
+		err = file.Chmod(perm)
+	}
+	if err == nil {
+		_, err = file.Write(data)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// selectWithJumps 按名称选择服务器，并包含它们（递归）引用的跳板机，保证导出的配置可以独立使用
+func selectWithJumps(servers []models.Server, names []string) ([]models.Server, error) {
+	byName := serversByName(servers)
+	selected := make(map[string]bool)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if selected[name] {
+			return nil
+		}
+		server, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("服务器 '%s' 不存在", name)
+		}
+		selected[name] = true
+		for _, jump := range server.Jump {
+			if err := visit(jump); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []models.Server
+	for _, server := range servers {
+		if selected[server.Name] {
+			result = append(result, server)
+		}
+	}
+	return result, nil
+}
+
+// redactServer 清除服务器配置中的密码、口令和代理地址中的密码
+func redactServer(server *models.Server) {
+	storage.RedactSecrets(server)
+	if server.Proxy == "" || server.Proxy == ssh.ProxyNone {
+		return
+	}
+	if u, err := ssh.ParseProxy(server.Proxy); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.User(u.User.Username())
+			server.Proxy = u.String()
+		}
+	}
+}
+
+// exportSSHConfig 生成 OpenSSH 客户端配置，跳板机以 ProxyJump 引用导出的 Host 别名
+func exportSSHConfig(w io.Writer, servers []models.Server) error {
+	fmt.Fprintln(w, "# 由 goss export 生成")
+	for _, server := range servers {
+		fmt.Fprintf(w, "\nHost %s\n", sshConfigQuote(server.Name))
+		fmt.Fprintf(w, "    HostName %s\n", server.Host)
+		fmt.Fprintf(w, "    Port %d\n", server.Port)
+		fmt.Fprintf(w, "    User %s\n", sshConfigQuote(server.Username))
+		if server.IdentityFile != "" {
+			fmt.Fprintf(w, "    IdentityFile %s\n", sshConfigQuote(server.IdentityFile))
+		}
+		if server.CertificateFile != "" {
+			fmt.Fprintf(w, "    CertificateFile %s\n", sshConfigQuote(server.CertificateFile))
+		}
+		if len(server.Jump) > 0 {
+			fmt.Fprintf(w, "    ProxyJump %s\n", strings.Join(server.Jump, ","))
+		} else if command := proxyCommand(server.Proxy); command != "" {
+			fmt.Fprintf(w, "    ProxyCommand %s\n", command)
+		}
+		if server.ForwardAgent {
+			fmt.Fprintln(w, "    ForwardAgent yes")
+		}
+		if server.StrictHostKeyChecking != "" {
+			fmt.Fprintf(w, "    StrictHostKeyChecking %s\n", server.StrictHostKeyChecking)
+		}
+		if server.KeepaliveInterval > 0 {
+			fmt.Fprintf(w, "    ServerAliveInterval %d\n", server.KeepaliveInterval)
+		}
+		if server.KeepaliveCountMax > 0 {
+			fmt.Fprintf(w, "    ServerAliveCountMax %d\n", server.KeepaliveCountMax)
+		}
+		for _, algos := range []struct {
+			keyword string
+			names   []string
+		}{
+			{"Ciphers", server.Ciphers},
+			{"KexAlgorithms", server.KeyExchanges},
+			{"MACs", server.MACs},
+			{"HostKeyAlgorithms", server.HostKeyAlgorithms},
+		} {
+			if len(algos.names) > 0 {
+				fmt.Fprintf(w, "    %s %s\n", algos.keyword, strings.Join(algos.names, ","))
+			}
+		}
+	}
+	return nil
+}
+
+// sshConfigQuote 为包含空格的参数加上双引号
+func sshConfigQuote(value string) string {
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// proxyCommand 将出站代理转换为使用 OpenBSD nc 的 ProxyCommand，不使用代理时返回空字符串
+func proxyCommand(proxy string) string {
+	if proxy == "" || proxy == ssh.ProxyNone {
+		return ""
+	}
+	u, err := ssh.ParseProxy(proxy)
+	if err != nil {
+		return ""
+	}
+
+	version := "5"
+	if u.Scheme == "http" {
+		version = "connect"
+	}
+	command := fmt.Sprintf("nc -X %s -x %s", version, u.Host)
+	if u.User != nil && u.Scheme == "http" {
+		command += " -P " + u.User.Username()
+	}
+	return command + " %h %p"
+}
+
+// ansibleVars 返回服务器对应的 Ansible 主机变量（按固定顺序）
+// 跳板机转换为 ansible_ssh_common_args 中的 ProxyJump，以 user@host:port 的形式引用
+func ansibleVars(server models.Server, byName map[string]models.Server) [][2]string {
+	vars := [][2]string{
+		{"ansible_host", server.Host},
+		{"ansible_port", strconv.Itoa(server.Port)},
+		{"ansible_user", server.Username},
+	}
+	if server.IdentityFile != "" {
+		vars = append(vars, [2]string{"ansible_ssh_private_key_file", server.IdentityFile})
+	}
+	if server.Password != "" {
+		vars = append(vars, [2]string{"ansible_password", server.Password})
+	}
+
+	var jumps []string
+	for _, jump := range jumpChain(server, byName, []string{server.Name}) {
+		jumps = append(jumps, fmt.Sprintf("%s@%s", jump.Username, net.JoinHostPort(jump.Host, strconv.Itoa(jump.Port))))
+	}
+	if len(jumps) > 0 {
+		vars = append(vars, [2]string{"ansible_ssh_common_args", "-o ProxyJump=" + strings.Join(jumps, ",")})
+	}
+	return vars
+}
+
+// jumpChain 按连接顺序展开服务器的跳板机链（跳板机自身的跳板机排在前面），忽略循环引用
+func jumpChain(server models.Server, byName map[string]models.Server, path []string) []models.Server {
+	var chain []models.Server
+	for _, name := range server.Jump {
+		jump, ok := byName[name]
+		if !ok || slices.Contains(path, name) {
+			continue
+		}
+		chain = append(chain, jumpChain(jump, byName, append(path, name))...)
+		chain = append(chain, jump)
+	}
+	return chain
+}
+
+// serversByName 按名称索引服务器
+func serversByName(servers []models.Server) map[string]models.Server {
+	byName := make(map[string]models.Server, len(servers))
+	for _, server := range servers {
+		byName[server.Name] = server
+	}
+	return byName
+}
+
+// ansibleGroups 将服务器标签转换为 Ansible 主机组，返回按组名排序的组名和每个组的主机
+// 分组标签直接作为组名，键值对标签 env=prod 转换为 env_prod，组名中的非法字符替换为下划线
+func ansibleGroups(servers []models.Server) ([]string, map[string][]string) {
+	hosts := make(map[string][]string)
+	for _, server := range servers {
+		for _, tag := range server.Tags {
+			// 手工编辑的配置文件中的标签没有经过校验，跳过空标签
+			if tag == "" {
+				continue
+			}
+			group := strings.Map(func(r rune) rune {
+				if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+					return r
+				}
+				return '_'
+			}, tag)
+			if group[0] >= '0' && group[0] <= '9' {
+				group = "_" + group
+			}
+			if !slices.Contains(hosts[group], server.Name) {
+				hosts[group] = append(hosts[group], server.Name)
+			}
+		}
+	}
+
+	groups := make([]string, 0, len(hosts))
+	for group := range hosts {
+		groups = append(groups, group)
+	}
+	slices.Sort(groups)
+	return groups, hosts
+}
+
+// exportAnsibleINI 生成 INI 格式的 Ansible 清单，主机变量定义在开头，标签对应的主机组只列出主机名
+func exportAnsibleINI(w io.Writer, servers []models.Server) error {
+	byName := serversByName(servers)
+	fmt.Fprintln(w, "# 由 goss export 生成")
+	for _, server := range servers {
+		line := server.Name
+		for _, v := range ansibleVars(server, byName) {
+			line += " " + v[0] + "=" + iniQuote(v[1])
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	groups, hosts := ansibleGroups(servers)
+	for _, group := range groups {
+		fmt.Fprintf(w, "\n[%s]\n", group)
+		for _, host := range hosts[group] {
+			fmt.Fprintln(w, host)
+		}
+	}
+	return nil
+}
+
+// iniQuote 为包含空格、引号等特殊字符的值加上单引号（Ansible 使用 shell 规则解析 INI 清单中的变量）
+func iniQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t'\"#=\\") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// exportAnsibleYAML 生成 YAML 格式的 Ansible 清单
+func exportAnsibleYAML(w io.Writer, servers []models.Server) error {
+	byName := serversByName(servers)
+	fmt.Fprintln(w, "# 由 goss export 生成")
+	fmt.Fprintln(w, "all:")
+	if len(servers) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "  hosts:")
+	for _, server := range servers {
+		fmt.Fprintf(w, "    %s:\n", yamlQuote(server.Name))
+		for _, v := range ansibleVars(server, byName) {
+			value := yamlQuote(v[1])
+			if v[0] == "ansible_port" {
+				value = v[1]
+			}
+			fmt.Fprintf(w, "      %s: %s\n", v[0], value)
+		}
+	}
+
+	groups, hosts := ansibleGroups(servers)
+	if len(groups) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "  children:")
+	for _, group := range groups {
+		fmt.Fprintf(w, "    %s:\n", group)
+		fmt.Fprintln(w, "      hosts:")
+		for _, host := range hosts[group] {
+			fmt.Fprintf(w, "        %s: {}\n", yamlQuote(host))
+		}
+	}
+	return nil
+}
+
+// yamlQuote 将字符串转换为 YAML 双引号标量（JSON 字符串是合法的 YAML）
+func yamlQuote(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// exportCSV 生成 CSV 表格，每个服务器一行
+func exportCSV(w io.Writer, servers []models.Server) error {
+	writer := csv.NewWriter(w)
+	header := []string{"name", "host", "port", "username", "auth", "identity_file", "jump", "proxy", "tags"}
+	if exportIncludeSecrets {
+		header = append(header, "password")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		record := []string{
+			server.Name,
+			server.Host,
+			strconv.Itoa(server.Port),
+			server.Username,
+			describeAuth(server),
+			server.IdentityFile,
+			strings.Join(server.Jump, ","),
+			server.Proxy,
+			strings.Join(server.Tags, ","),
+		}
+		if exportIncludeSecrets {
+			record = append(record, server.Password)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportJSON 生成与配置文件中 servers 字段相同结构的 JSON
+func exportJSON(w io.Writer, servers []models.Server) error {
+	if servers == nil {
+		servers = []models.Server{}
+	}
+	data, err := json.MarshalIndent(servers, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "ssh-config", "导出格式: ssh-config、ansible-ini、ansible-yaml、csv、json")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "输出文件（默认输出到标准输出）")
+	exportCmd.Flags().BoolVar(&exportIncludeSecrets, "include-secrets", false, "导出密码、私钥口令等敏感字段")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"goSSH/internal/config"
+	"goSSH/internal/secret"
+	"goSSH/internal/ssh"
+)
+
+var (
+	forwardSocksUser        string // --user 标志，SOCKS5 认证用户名
+	forwardSocksPasswordRef string // --password-ref 标志，SOCKS5 认证密码引用
+)
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward",
+	Short: "SSH端口转发",
+	Long:  "通过SSH连接建立端口转发，保持运行直到按 Ctrl+C",
+}
+
+var forwardLocalCmd = &cobra.Command{
+	Use:   "local <name> <[bind_address:]port:host:hostport>...",
+	Short: "本地端口转发（等同于 ssh -L）",
+	Long: `在本地监听端口，并通过服务器将连接转发到目标地址（等同于 ssh -L）。
+未指定监听地址时只监听 127.0.0.1，可以同时指定多条转发规则。
+
+示例:
+  goss forward local prod 5432:db.internal:5432
+  goss forward local prod 8080:localhost:80 0.0.0.0:6379:redis:6379`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var specs []ssh.ForwardSpec
+		for _, arg := range args[1:] {
+			spec, err := ssh.ParseForwardSpec(arg, "127.0.0.1")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			specs = append(specs, spec)
+		}
+
+		client, err := connectForward(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		defer client.Close()
+
+		create := func(spec ssh.ForwardSpec) (*ssh.Forwarder, error) {
+			return ssh.ForwardLocal(client, spec)
+		}
+		if err := runForwarders(client, specs, "L", create); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var forwardRemoteCmd = &cobra.Command{
+	Use:   "remote <name> <[bind_address:]port:host:hostport>...",
+	Short: "远程端口转发（等同于 ssh -R）",
+	Long: `请求服务器在远程端口上监听，并将连接转发到本机可以访问的目标地址（等同于 ssh -R）。
+未指定监听地址时由服务器监听 localhost，监听其他网卡需要服务器开启 GatewayPorts。
+远程端口为 0 时由服务器分配端口。
+
+示例:
+  goss forward remote prod 8080:localhost:3000
+  goss forward remote prod 10.0.0.5:9000:127.0.0.1:9000`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var specs []ssh.ForwardSpec
+		for _, arg := range args[1:] {
+			spec, err := ssh.ParseForwardSpec(arg, "localhost")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			specs = append(specs, spec)
+		}
+
+		client, err := connectForward(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		defer client.Close()
+
+		create := func(spec ssh.ForwardSpec) (*ssh.Forwarder, error) {
+			return ssh.ForwardRemote(client, spec)
+		}
+		if err := runForwarders(client, specs, "R", create); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var forwardSocksCmd = &cobra.Command{
+	Use:   "socks <name> <[bind_address:]port>",
+	Short: "动态端口转发，在本地运行 SOCKS5 代理（等同于 ssh -D）",
+	Long: `在本地运行 SOCKS5 代理，客户端请求的每个目标地址都通过服务器连接（等同于 ssh -D）。
+目标主机名由服务器解析，因此可以访问只有服务器能解析的内网域名。
+未指定监听地址时只监听 127.0.0.1；使用 --user 时要求客户端进行用户名/密码认证。
+
+示例:
+  goss forward socks bastion 1080
+  goss forward socks bastion 0.0.0.0:1080 --user alice --password-ref env:SOCKS_PW`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		spec, err := ssh.ParseSocksSpec(args[1], "127.0.0.1")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		auth, err := socksAuth()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := connectForward(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		defer client.Close()
+
+		create := func(spec ssh.ForwardSpec) (*ssh.Forwarder, error) {
+			return ssh.ForwardSOCKS(client, spec, auth)
+		}
+		if err := runForwarders(client, []ssh.ForwardSpec{spec}, "D", create); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// socksAuth 根据命令行参数获取 SOCKS5 监听端的认证信息，未指定用户名时不启用认证
+func socksAuth() (*ssh.SocksAuth, error) {
+	if forwardSocksUser == "" {
+		return nil, nil
+	}
+
+	if forwardSocksPasswordRef != "" {
+		password, err := secret.Resolve(forwardSocksPasswordRef)
+		if err != nil {
+			return nil, err
+		}
+		return &ssh.SocksAuth{Username: forwardSocksUser, Password: password}, nil
+	}
+
+	prompt := promptui.Prompt{
+		Label: fmt.Sprintf("SOCKS5 用户 %s 的密码", forwardSocksUser),
+		Mask:  '*',
+		Validate: func(input string) error {
+			if input == "" {
+				return fmt.Errorf("密码不能为空")
+			}
+			return nil
+		},
+	}
+	password, err := prompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("输入取消: %v", err)
+	}
+	return &ssh.SocksAuth{Username: forwardSocksUser, Password: password}, nil
+}
+
+// connectForward 连接用于端口转发的服务器
+func connectForward(name string) (*ssh.Client, error) {
+	manager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := resolveServer(manager, name, "选择服务器")
+	if err != nil {
+		return nil, err
+	}
+
+	// 端口转发独占连接，不经过主连接进程
+	client := ssh.NewClient(server)
+	client.DisableMaster()
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// runForwarders 运行端口转发，直到收到中断信号
+// SSH连接断开时会按指数退避自动重连，并使用 create 重新建立所有转发
+// kind 为日志前缀中的转发类型标识，如 L、R、D
+func runForwarders(client *ssh.Client, specs []ssh.ForwardSpec, kind string, create func(ssh.ForwardSpec) (*ssh.Forwarder, error)) error {
+	warnColor := color.New(color.FgYellow)
+	name := client.GetServer().Name
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	for {
+		forwarders, err := startForwarders(specs, kind, create)
+		if err != nil {
+			return err
+		}
+		// 监听端口为 0 时，重连后继续使用已分配的端口
+		for i, f := range forwarders {
+			specs[i] = f.Spec
+		}
+
+		errCh := make(chan error, len(forwarders))
+		for _, f := range forwarders {
+			go func(f *ssh.Forwarder) {
+				if err := f.Serve(); err != nil {
+					errCh <- err
+				}
+			}(f)
+		}
+		fmt.Printf("已连接到 %s，按 Ctrl+C 停止转发\n", name)
+
+		select {
+		case <-sigCh:
+			fmt.Println("\n正在停止转发...")
+			closeForwarders(forwarders)
+			return nil
+		case err := <-errCh:
+			// 连接断开时远程转发的监听也会返回错误，稍等片刻以区分两种情况
+			select {
+			case <-client.Done():
+			case <-time.After(time.Second):
+				closeForwarders(forwarders)
+				return err
+			}
+		case <-client.Done():
+		}
+		closeForwarders(forwarders)
+
+		warnColor.Printf("与服务器 '%s' 的连接已断开: %v，正在重连...\n", name, client.Err())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-sigCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err = client.ReconnectWithBackoff(ctx, func(attempt int, delay time.Duration, err error) {
+			warnColor.Printf("第 %d 次重连失败: %v，%s 后重试\n", attempt, err, delay.Round(time.Second))
+		})
+		interrupted := ctx.Err() != nil
+		cancel()
+
+		if interrupted {
+			fmt.Println("\n已停止转发")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// startForwarders 根据转发规则建立所有转发，任一失败时关闭已建立的转发
+func startForwarders(specs []ssh.ForwardSpec, kind string, create func(ssh.ForwardSpec) (*ssh.Forwarder, error)) ([]*ssh.Forwarder, error) {
+	successColor := color.New(color.FgGreen)
+	tagColor := color.New(color.FgCyan)
+
+	var forwarders []*ssh.Forwarder
+	for _, spec := range specs {
+		f, err := create(spec)
+		if err != nil {
+			closeForwarders(forwarders)
+			return nil, err
+		}
+
+		tag := tagColor.Sprintf("[%s %d]", kind, f.Spec.BindPort)
+		f.Logf = func(format string, args ...any) {
+			fmt.Printf("%s %s %s\n", time.Now().Format("15:04:05"), tag, fmt.Sprintf(format, args...))
+		}
+		successColor.Printf("✓ %s %s\n", tag, f.Spec)
+		forwarders = append(forwarders, f)
+	}
+	return forwarders, nil
+}
+
+// closeForwarders 并发关闭所有端口转发
+func closeForwarders(forwarders []*ssh.Forwarder) {
+	var wg sync.WaitGroup
+	for _, f := range forwarders {
+		wg.Add(1)
+		go func(f *ssh.Forwarder) {
+			defer wg.Done()
+			f.Close()
+		}(f)
+	}
+	wg.Wait()
+}
+
+func init() {
+	forwardSocksCmd.Flags().StringVar(&forwardSocksUser, "user", "", "要求客户端使用的用户名（启用用户名/密码认证）")
+	forwardSocksCmd.Flags().StringVar(&forwardSocksPasswordRef, "password-ref", "", "认证密码的引用，如 env:SOCKS_PW（未指定时交互式输入）")
+
+	forwardCmd.AddCommand(forwardLocalCmd)
+	forwardCmd.AddCommand(forwardRemoteCmd)
+	forwardCmd.AddCommand(forwardSocksCmd)
+	rootCmd.AddCommand(forwardCmd)
+}
@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"goSSH/internal/config"
+	"goSSH/internal/ssh"
+	"goSSH/models"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+var (
+	hostkeysScanAll  bool // --all 标志，扫描所有服务器
+	hostkeysParallel int  // --parallel 标志，扫描多个服务器时的最大并发数
+	hostkeysYes      bool // --yes 标志，跳过确认
+)
+
+var hostkeysCmd = &cobra.Command{
+	Use:   "hostkeys",
+	Short: "管理已记录的主机密钥",
+	Long:  "查看、扫描、更新和删除已记录（固定）的服务器主机密钥",
+}
+
+var hostkeysListCmd = &cobra.Command{
+	Use:   "list [name]",
+	Short: "列出已记录的主机密钥",
+	Long:  "列出每个服务器已记录的主机密钥类型和 SHA256 指纹，未提供名称时列出所有服务器",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		var servers []models.Server
+		if len(args) > 0 {
+			servers, err = manager.Select(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+		} else {
+			servers, err = manager.ListServers()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+		}
+
+		if len(servers) == 0 {
+			fmt.Println("没有配置任何服务器")
+			return
+		}
+
+		store, err := ssh.NewKnownHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		headerColor := color.New(color.FgCyan, color.Bold)
+		headerColor.Printf("\n%-20s %-26s %-22s %s\n", "名称", "地址", "类型", "指纹")
+		fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────────")
+
+		for _, server := range servers {
+			address := ssh.ServerAddress(&server)
+			keys, err := store.Lookup(address)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+
+			if len(keys) == 0 {
+				fmt.Printf("%-20s %-26s %-22s %s\n", server.Name, address, "-", "(未记录)")
+				continue
+			}
+			for _, key := range keys {
+				fmt.Printf("%-20s %-26s %-22s %s\n", server.Name, address, key.Type(), cryptossh.FingerprintSHA256(key))
+			}
+		}
+		fmt.Printf("\n记录文件: %s\n\n", store.Path())
+	},
+}
+
+// hostKeyScanResult 表示一个服务器的扫描结果
+type hostKeyScanResult struct {
+	server models.Server
+	diffs  []ssh.HostKeyDiff
+	err    error
+}
+
+// changed 判断扫描结果是否与记录不一致（已记录的密钥被更换或不再提供）
+// 首次连接时只记录协商使用的密钥类型，服务器提供的其他未记录类型只作为提示，不算变化
+func (r *hostKeyScanResult) changed() bool {
+	for _, d := range r.diffs {
+		if d.Status == ssh.HostKeyChanged || d.Status == ssh.HostKeyMissing {
+			return true
+		}
+	}
+	return false
+}
+
+// pinned 判断服务器是否有已记录的主机密钥
+func (r *hostKeyScanResult) pinned() bool {
+	for _, d := range r.diffs {
+		if d.Pinned != nil {
+			return true
+		}
+	}
+	return false
+}
+
+var hostkeysScanCmd = &cobra.Command{
+	Use:   "scan [name]",
+	Short: "重新扫描服务器的主机密钥并与记录比较",
+	Long:  "连接服务器获取当前的主机密钥，并与已记录的密钥比较，显示变化情况。使用 --all 并发扫描所有服务器",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		var servers []models.Server
+		if hostkeysScanAll {
+			servers, err = manager.ListServers()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+			if len(servers) == 0 {
+				fmt.Println("没有配置任何服务器")
+				return
+			}
+		} else if len(args) > 0 {
+			// 选择器匹配到多个服务器时全部扫描
+			servers, err = manager.Select(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+		} else {
+			server, err := hostkeysTarget(manager, args, "选择要扫描的服务器")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+			servers = []models.Server{*server}
+		}
+
+		store, err := ssh.NewKnownHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		// 并发扫描，按配置顺序输出结果
+		results := make([]hostKeyScanResult, len(servers))
+		ssh.RunParallel(len(servers), hostkeysParallel, func(i int) {
+			results[i] = scanServerHostKeys(store, servers[i])
+		})
+
+		failed := false
+		for i := range results {
+			printScanResult(&results[i])
+			if results[i].err != nil || results[i].changed() {
+				failed = true
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var hostkeysAcceptCmd = &cobra.Command{
+	Use:   "accept [name]",
+	Short: "扫描并记录服务器当前的主机密钥",
+	Long:  "重新扫描服务器的主机密钥，确认后替换已记录的密钥（用于服务器密钥更换后）",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		server, err := hostkeysTarget(manager, args, "选择服务器")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		store, err := ssh.NewKnownHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		result := scanServerHostKeys(store, *server)
+		printScanResult(&result)
+		if result.err != nil {
+			os.Exit(1)
+		}
+
+		if result.pinned() && !result.changed() {
+			fmt.Println("主机密钥与记录一致，无需更新")
+			return
+		}
+
+		if !hostkeysYes {
+			prompt := promptui.Prompt{
+				Label:     fmt.Sprintf("确认使用以上扫描到的密钥替换 '%s' 的记录", server.Name),
+				IsConfirm: true,
+			}
+			if _, err := prompt.Run(); err != nil {
+				fmt.Println("操作已取消")
+				return
+			}
+		}
+
+		var keys []cryptossh.PublicKey
+		for _, d := range result.diffs {
+			if d.Scanned != nil {
+				keys = append(keys, d.Scanned)
+			}
+		}
+
+		if err := store.Replace(ssh.ServerAddress(server), keys); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ 已更新 '%s' 的主机密钥记录\n", server.Name)
+	},
+}
+
+var hostkeysRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "删除服务器已记录的主机密钥",
+	Long:  "删除服务器已记录的所有主机密钥，下次连接时将重新询问是否信任",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		server, err := hostkeysTarget(manager, args, "选择服务器")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		if !hostkeysYes {
+			prompt := promptui.Prompt{
+				Label:     fmt.Sprintf("确认删除 '%s' 的主机密钥记录", server.Name),
+				IsConfirm: true,
+			}
+			if _, err := prompt.Run(); err != nil {
+				fmt.Println("操作已取消")
+				return
+			}
+		}
+
+		store, err := ssh.NewKnownHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		removed, err := store.Remove(ssh.ServerAddress(server))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		if removed == 0 {
+			fmt.Printf("'%s' 没有已记录的主机密钥\n", server.Name)
+			return
+		}
+		fmt.Printf("✓ 已删除 '%s' 的 %d 条主机密钥记录\n", server.Name, removed)
+	},
+}
+
+// hostkeysTarget 根据参数获取目标服务器，未提供名称时交互式选择
+func hostkeysTarget(manager *config.Manager, args []string, label string) (*models.Server, error) {
+	if len(args) > 0 {
+		return resolveServer(manager, args[0], label)
+	}
+	return selectServer(manager, label)
+}
+
+// scanServerHostKeys 扫描服务器的主机密钥并与记录比较
+func scanServerHostKeys(store *ssh.KnownHosts, server models.Server) hostKeyScanResult {
+	result := hostKeyScanResult{server: server}
+
+	pinned, err := store.Lookup(ssh.ServerAddress(&server))
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	scanned, err := ssh.ScanHostKeys(&server)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	result.diffs = ssh.DiffHostKeys(pinned, scanned)
+	return result
+}
+
+// printScanResult 输出一个服务器的扫描结果
+func printScanResult(result *hostKeyScanResult) {
+	titleColor := color.New(color.FgCyan, color.Bold)
+	okColor := color.New(color.FgGreen)
+	warnColor := color.New(color.FgYellow)
+	errColor := color.New(color.FgRed, color.Bold)
+
+	titleColor.Printf("\n%s (%s)\n", result.server.Name, ssh.ServerAddress(&result.server))
+
+	if result.err != nil {
+		errColor.Printf("  ✗ 扫描失败: %v\n", result.err)
+		return
+	}
+
+	for _, d := range result.diffs {
+		switch d.Status {
+		case ssh.HostKeyUnchanged:
+			okColor.Printf("  ✓ %-22s %s\n", d.Type, cryptossh.FingerprintSHA256(d.Scanned))
+		case ssh.HostKeyChanged:
+			errColor.Printf("  ✗ %-22s 已变更\n", d.Type)
+			fmt.Printf("      - %s\n", cryptossh.FingerprintSHA256(d.Pinned))
+			fmt.Printf("      + %s\n", cryptossh.FingerprintSHA256(d.Scanned))
+		case ssh.HostKeyAdded:
+			fmt.Printf("  + %-22s %s (未记录)\n", d.Type, cryptossh.FingerprintSHA256(d.Scanned))
+		case ssh.HostKeyMissing:
+			warnColor.Printf("  - %-22s %s (服务器未提供)\n", d.Type, cryptossh.FingerprintSHA256(d.Pinned))
+		}
+	}
+}
+
+func init() {
+	hostkeysScanCmd.Flags().BoolVar(&hostkeysScanAll, "all", false, "并发扫描所有已配置的服务器")
+	hostkeysScanCmd.Flags().IntVarP(&hostkeysParallel, "parallel", "p", ssh.DefaultParallel, "扫描多个服务器时最多同时扫描的数量（0 表示不限制）")
+	hostkeysAcceptCmd.Flags().BoolVarP(&hostkeysYes, "yes", "y", false, "跳过确认")
+	hostkeysRemoveCmd.Flags().BoolVarP(&hostkeysYes, "yes", "y", false, "跳过确认")
+
+	hostkeysCmd.AddCommand(hostkeysListCmd)
+	hostkeysCmd.AddCommand(hostkeysScanCmd)
+	hostkeysCmd.AddCommand(hostkeysAcceptCmd)
+	hostkeysCmd.AddCommand(hostkeysRemoveCmd)
+	rootCmd.AddCommand(hostkeysCmd)
+}
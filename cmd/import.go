@@ -0,0 +1,438 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"goSSH/internal/config"
+	"goSSH/internal/ssh"
+	"goSSH/internal/sshconfig"
+	"goSSH/models"
+)
+
+// 同名服务器的处理策略
+const (
+	conflictSkip      = "skip"      // 跳过，保留已有配置
+	conflictOverwrite = "overwrite" // 将导入的配置合并到已有配置中
+	conflictRename    = "rename"    // 以 <名称>-2、<名称>-3 等新名称导入
+)
+
+var (
+	importDryRun     bool   // --dry-run 标志，只预览不保存
+	importOnConflict string // --on-conflict 标志，同名服务器的处理策略
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "从其他工具导入服务器配置",
+	Long:  "从其他工具的配置文件导入服务器配置",
+}
+
+var importSSHConfigCmd = &cobra.Command{
+	Use:   "ssh-config [path]",
+	Short: "从 OpenSSH 配置文件导入服务器",
+	Long: `解析 OpenSSH 客户端配置文件（默认 ~/.ssh/config）中的 Host 段，将每个具体的主机别名导入为服务器。
+支持 HostName、Port、User、IdentityFile、CertificateFile、ProxyJump、ForwardAgent、ServerAliveInterval、
+ServerAliveCountMax 和 Include，Host * 等通配符段中的配置作为默认值合并到匹配的主机中。
+同名服务器默认跳过，可以通过 --on-conflict 选择覆盖（只更新 ssh config 中的地址、端口、用户名、私钥等字段，
+保留原有的密码、TOTP、标签、代理、算法、隧道等其他配置）或重命名后导入。`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if importOnConflict != conflictSkip && importOnConflict != conflictOverwrite && importOnConflict != conflictRename {
+			fmt.Fprintf(os.Stderr, "错误: --on-conflict 必须是 skip、overwrite 或 rename\n")
+			os.Exit(1)
+		}
+
+		path := "~/.ssh/config"
+		if len(args) > 0 {
+			path = args[0]
+		}
+		path, err := ssh.ExpandPath(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		sshConfig, err := sshconfig.Load(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 覆盖时在保存的配置上合并，不能使用已合并全局设置的配置
+		existing, err := manager.StoredServers()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		entries, warnings := planImport(sshConfig, existing, importOnConflict)
+		warnColor := color.New(color.FgYellow)
+		for _, warning := range append(sshConfig.Warnings, warnings...) {
+			warnColor.Fprintf(os.Stderr, "警告: %s\n", warning)
+		}
+
+		if len(entries) == 0 {
+			fmt.Printf("%s 中没有可导入的主机\n", path)
+			return
+		}
+
+		printImportPlan(entries)
+		if importDryRun {
+			fmt.Println("预览模式，未保存任何配置")
+			return
+		}
+
+		imported, failed := 0, 0
+		for _, entry := range entries {
+			var err error
+			switch entry.action {
+			case importSkip:
+				continue
+			case importOverwrite:
+				err = manager.UpdateServer(entry.server)
+			default:
+				err = manager.AddServer(entry.server)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ 导入 '%s' 失败: %v\n", entry.server.Name, err)
+				failed++
+				continue
+			}
+			imported++
+		}
+
+		fmt.Printf("✓ 已导入 %d 个服务器\n", imported)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// 导入时对每个主机的处理方式
+const (
+	importAdd       = "新增"
+	importSkip      = "跳过（已存在）"
+	importOverwrite = "覆盖"
+	importRename    = "重命名"
+)
+
+// importEntry 表示一个待导入的服务器
+type importEntry struct {
+	alias  string        // ssh config 中的主机别名
+	server models.Server // 转换后的服务器配置
+	action string        // 处理方式
+}
+
+// jumpSpec 表示 ProxyJump 中的一跳 [user@]host[:port]
+type jumpSpec struct {
+	raw  string
+	user string
+	host string
+	port int
+}
+
+// planImport 将 ssh config 中的主机转换为服务器配置，并按冲突策略确定每个服务器的处理方式
+// ProxyJump 中引用的主机不是 ssh config 中的别名时，会额外导入一个以该跳板机命名的服务器
+func planImport(sshConfig *sshconfig.Config, existing []models.Server, onConflict string) ([]importEntry, []string) {
+	var warnings []string
+	taken := make(map[string]bool)
+	existingServers := make(map[string]models.Server)
+	for _, s := range existing {
+		taken[s.Name] = true
+		existingServers[s.Name] = s
+	}
+
+	var entries []importEntry
+	names := make(map[string]string) // 别名 -> 导入后的服务器名称
+	var jumps [][]jumpSpec           // 与 entries 对应，每个服务器的跳板机
+
+	addEntry := func(alias string, server models.Server, jump []jumpSpec) {
+		entry := importEntry{alias: alias, server: server, action: importAdd}
+		if taken[server.Name] {
+			switch onConflict {
+			case conflictSkip:
+				entry.action = importSkip
+			case conflictOverwrite:
+				entry.action = importOverwrite
+				entry.server = mergeImported(existingServers[server.Name], server, len(jump) > 0)
+			case conflictRename:
+				entry.action = importRename
+				for i := 2; ; i++ {
+					name := fmt.Sprintf("%s-%d", server.Name, i)
+					if !taken[name] {
+						entry.server.Name = name
+						break
+					}
+				}
+			}
+		}
+		if entry.action != importSkip {
+			taken[entry.server.Name] = true
+		}
+		names[alias] = entry.server.Name
+		entries = append(entries, entry)
+		jumps = append(jumps, jump)
+	}
+
+	for _, alias := range sshConfig.Hosts() {
+		server, jump, err := convertHost(sshConfig, alias)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("跳过主机 '%s': %v", alias, err))
+			continue
+		}
+		addEntry(alias, server, jump)
+	}
+
+	// 解析跳板机名称，不是 ssh config 别名的跳板机作为新服务器导入
+	for i := 0; i < len(entries); i++ {
+		for _, spec := range jumps[i] {
+			name, ok := names[spec.raw]
+			if !ok {
+				server, jump, err := convertJump(sshConfig, spec)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("主机 '%s' 的跳板机 '%s' 无法导入: %v", entries[i].alias, spec.raw, err))
+					continue
+				}
+				addEntry(spec.raw, server, jump)
+				name = names[spec.raw]
+			}
+			entries[i].server.Jump = append(entries[i].server.Jump, name)
+		}
+	}
+
+	return entries, warnings
+}
+
+// mergeImported 将导入的配置合并到已有的服务器配置中
+// 地址、端口和用户名总是更新，私钥、证书、agent 转发和保活只在 ssh config 中配置了时更新，
+// 配置了 ProxyJump 时清空原有的跳板机（之后填入导入的跳板机）；其他字段保留原有的值
+func mergeImported(old, imported models.Server, hasJump bool) models.Server {
+	merged := old
+	merged.Host = imported.Host
+	merged.Port = imported.Port
+	merged.Username = imported.Username
+	if imported.IdentityFile != "" {
+		merged.IdentityFile = imported.IdentityFile
+	}
+	if imported.CertificateFile != "" {
+		merged.CertificateFile = imported.CertificateFile
+	}
+	if imported.ForwardAgent {
+		merged.ForwardAgent = true
+	}
+	if imported.KeepaliveInterval != 0 {
+		merged.KeepaliveInterval = imported.KeepaliveInterval
+	}
+	if imported.KeepaliveCountMax != 0 {
+		merged.KeepaliveCountMax = imported.KeepaliveCountMax
+	}
+	if hasJump {
+		merged.Jump = nil
+	}
+	return merged
+}
+
+// convertHost 将 ssh config 中的一个主机别名转换为服务器配置
+func convertHost(sshConfig *sshconfig.Config, alias string) (models.Server, []jumpSpec, error) {
+	server := models.Server{
+		Name:     alias,
+		Host:     expandTokens(sshConfig.GetString(alias, "HostName"), alias, "", 0),
+		Port:     22,
+		Username: sshConfig.GetString(alias, "User"),
+	}
+	if server.Host == "" {
+		server.Host = alias
+	}
+
+	if port := sshConfig.GetString(alias, "Port"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return models.Server{}, nil, fmt.Errorf("端口 '%s' 无效", port)
+		}
+		server.Port = p
+	}
+
+	if server.Username == "" {
+		server.Username = localUsername()
+		if server.Username == "" {
+			return models.Server{}, nil, fmt.Errorf("未配置 User，且无法获取当前用户名")
+		}
+	}
+
+	if identity := sshConfig.GetString(alias, "IdentityFile"); identity != "" && !strings.EqualFold(identity, "none") {
+		server.IdentityFile = expandTokens(identity, server.Host, server.Username, server.Port)
+	}
+	if cert := sshConfig.GetString(alias, "CertificateFile"); cert != "" && !strings.EqualFold(cert, "none") {
+		server.CertificateFile = expandTokens(cert, server.Host, server.Username, server.Port)
+	}
+	server.ForwardAgent = strings.EqualFold(sshConfig.GetString(alias, "ForwardAgent"), "yes")
+
+	if interval := sshConfig.GetString(alias, "ServerAliveInterval"); interval != "" {
+		if v, err := strconv.Atoi(interval); err == nil && v > 0 {
+			server.KeepaliveInterval = v
+		}
+	}
+	if count := sshConfig.GetString(alias, "ServerAliveCountMax"); count != "" {
+		if v, err := strconv.Atoi(count); err == nil && v > 0 {
+			server.KeepaliveCountMax = v
+		}
+	}
+
+	if sshConfig.GetString(alias, "ProxyCommand") != "" && sshConfig.GetString(alias, "ProxyJump") == "" {
+		return models.Server{}, nil, fmt.Errorf("不支持 ProxyCommand，请改用 ProxyJump")
+	}
+
+	var jumps []jumpSpec
+	if proxyJump := sshConfig.GetString(alias, "ProxyJump"); proxyJump != "" && !strings.EqualFold(proxyJump, "none") {
+		for _, raw := range strings.Split(proxyJump, ",") {
+			spec, err := parseJumpSpec(strings.TrimSpace(raw))
+			if err != nil {
+				return models.Server{}, nil, err
+			}
+			jumps = append(jumps, spec)
+		}
+	}
+	return server, jumps, nil
+}
+
+// convertJump 将 ProxyJump 中引用的主机转换为服务器配置
+// 与 OpenSSH 相同，跳板机的主机名同样会匹配 ssh config 中的 Host 段，ProxyJump 中指定的用户名和端口优先
+func convertJump(sshConfig *sshconfig.Config, spec jumpSpec) (models.Server, []jumpSpec, error) {
+	server, jumps, err := convertHost(sshConfig, spec.host)
+	if err != nil {
+		return models.Server{}, nil, err
+	}
+	server.Name = spec.raw
+	if spec.user != "" {
+		server.Username = spec.user
+	}
+	if spec.port != 0 {
+		server.Port = spec.port
+	}
+	return server, jumps, nil
+}
+
+// parseJumpSpec 解析 ProxyJump 中的一跳，支持 [user@]host[:port] 和 ssh://[user@]host[:port]
+func parseJumpSpec(raw string) (jumpSpec, error) {
+	spec := jumpSpec{raw: raw}
+	rest := strings.TrimPrefix(raw, "ssh://")
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		spec.user, rest = rest[:at], rest[at+1:]
+	}
+
+	spec.host = rest
+	if host, port, err := net.SplitHostPort(rest); err == nil {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return jumpSpec{}, fmt.Errorf("跳板机 '%s' 的端口无效", raw)
+		}
+		spec.host, spec.port = host, p
+	}
+	if spec.host == "" {
+		return jumpSpec{}, fmt.Errorf("跳板机 '%s' 格式无效", raw)
+	}
+
+	// 只有主机名时直接使用主机名作为服务器名称，便于与 ssh config 中的别名对应
+	if spec.user == "" && spec.port == 0 {
+		spec.raw = spec.host
+	}
+	return spec, nil
+}
+
+// expandTokens 展开 ssh config 中常用的 % 占位符，开头的 ~ 保留原样，连接时再展开
+// 支持 %h（主机名）、%p（端口）、%r（远程用户名）、%u（本地用户名）、%d（本地主目录）和 %%
+func expandTokens(value, host, remoteUser string, port int) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+
+	home, _ := os.UserHomeDir()
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'h':
+			b.WriteString(host)
+		case 'p':
+			b.WriteString(strconv.Itoa(port))
+		case 'r':
+			b.WriteString(remoteUser)
+		case 'u':
+			b.WriteString(localUsername())
+		case 'd':
+			b.WriteString(home)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// localUsername 返回当前本地用户名（不含 Windows 域名），获取失败时返回空字符串
+func localUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	name := u.Username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// printImportPlan 以表格形式输出导入计划
+func printImportPlan(entries []importEntry) {
+	headerColor := color.New(color.FgCyan, color.Bold)
+	detailColor := color.New(color.FgHiBlack)
+	headerColor.Printf("\n%-20s %-24s %-8s %-15s %-20s %s\n", "名称", "主机", "端口", "用户名", "跳板机", "操作")
+	fmt.Println("──────────────────────────────────────────────────────────────────────────────────────────────")
+
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		action := entry.action
+		if action == importRename {
+			action = fmt.Sprintf("重命名（原名 %s）", entry.alias)
+		}
+		jump := strings.Join(entry.server.Jump, ",")
+		if jump == "" {
+			jump = "-"
+		}
+		fmt.Printf("%-20s %-24s %-8d %-15s %-20s %s\n",
+			entry.server.Name, entry.server.Host, entry.server.Port, entry.server.Username, jump, action)
+		if entry.server.IdentityFile != "" {
+			detailColor.Printf("  私钥: %s\n", entry.server.IdentityFile)
+		}
+		counts[entry.action]++
+	}
+
+	fmt.Printf("\n新增 %d 个，覆盖 %d 个，重命名 %d 个，跳过 %d 个\n\n",
+		counts[importAdd], counts[importOverwrite], counts[importRename], counts[importSkip])
+}
+
+func init() {
+	importSSHConfigCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "只预览将要导入的服务器，不保存")
+	importSSHConfigCmd.Flags().StringVar(&importOnConflict, "on-conflict", conflictSkip, "同名服务器的处理策略: skip（跳过）、overwrite（覆盖）、rename（重命名后导入）")
+	importCmd.AddCommand(importSSHConfigCmd)
+	rootCmd.AddCommand(importCmd)
+}
@@ -30,7 +30,7 @@ func runInteractiveMode() {
 	}
 
 	banner := color.New(color.FgCyan, color.Bold)
-	banner.Println(`
+	banner.Print(`
 ╔══════════════════════════════════════╗
 ║      GoSSH 交互式菜单模式            ║
 ╚══════════════════════════════════════╝
@@ -88,6 +88,7 @@ func runInteractiveMode() {
 	}
 }
 
+// selectServer 交互式选择服务器，配置了分组时先选择分组
 func selectServer(manager *config.Manager, label string) (*models.Server, error) {
 	servers, err := manager.ListServers()
 	if err != nil {
@@ -98,22 +99,29 @@ func selectServer(manager *config.Manager, label string) (*models.Server, error)
 		return nil, fmt.Errorf("没有配置任何服务器")
 	}
 
-	items := make([]string, len(servers))
-	for i, s := range servers {
-		items[i] = fmt.Sprintf("%s (%s:%d - %s)", s.Name, s.Host, s.Port, s.Username)
-	}
-
-	prompt := promptui.Select{
-		Label: label,
-		Items: items,
-	}
+	if groups := config.Groups(servers); len(groups) > 0 {
+		selectors := make([]*config.Selector, len(groups))
+		items := make([]string, len(groups)+1)
+		items[0] = fmt.Sprintf("全部服务器（%d）", len(servers))
+		for i, group := range groups {
+			selectors[i], _ = config.ParseSelector("@" + group)
+			items[i+1] = fmt.Sprintf("@%s（%d）", group, len(config.Filter(servers, selectors[i])))
+		}
 
-	index, _, err := prompt.Run()
-	if err != nil {
-		return nil, err
+		prompt := promptui.Select{
+			Label: "选择分组",
+			Items: items,
+		}
+		index, _, err := prompt.Run()
+		if err != nil {
+			return nil, err
+		}
+		if index > 0 {
+			servers = config.Filter(servers, selectors[index-1])
+		}
 	}
 
-	return &servers[index], nil
+	return pickServer(servers, label)
 }
 
 func handleConnect(manager *config.Manager) {
@@ -3,16 +3,28 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"goSSH/internal/config"
+	"goSSH/internal/ssh"
+	"goSSH/models"
+)
+
+var (
+	listLong  bool // -l 标志，显示详细信息
+	listGroup bool // -g 标志，按分组显示
 )
 
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [selector]",
 	Short: "列出所有已配置的SSH服务器",
-	Long:  "显示所有已保存的SSH服务器配置信息",
+	Long: `显示所有已保存的SSH服务器配置信息，使用 -l 显示私钥、证书、跳板机等详细信息，并检查证书是否过期。
+提供选择器（如 @web,env=prod）时只显示匹配的服务器，使用 -g 按分组显示。`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		manager, err := config.NewManager()
 		if err != nil {
@@ -20,7 +32,12 @@ var listCmd = &cobra.Command{
 			return
 		}
 
-		servers, err := manager.ListServers()
+		var servers []models.Server
+		if len(args) > 0 {
+			servers, err = manager.Select(args[0])
+		} else {
+			servers, err = manager.ListServers()
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 			return
@@ -31,22 +48,126 @@ var listCmd = &cobra.Command{
 			return
 		}
 
-		headerColor := color.New(color.FgCyan, color.Bold)
-		headerColor.Printf("\n%-20s %-20s %-8s %-15s\n", "名称", "主机", "端口", "用户名")
-		fmt.Println("────────────────────────────────────────────────────────────")
+		if !listGroup {
+			printServerTable(servers)
+			fmt.Println()
+			return
+		}
+
+		groupColor := color.New(color.FgMagenta, color.Bold)
+		for _, group := range config.Groups(servers) {
+			selector, _ := config.ParseSelector("@" + group)
+			members := config.Filter(servers, selector)
+			groupColor.Printf("\n@%s（%d 个服务器）", group, len(members))
+			printServerTable(members)
+		}
 
-		for _, server := range servers {
-			fmt.Printf("%-20s %-20s %-8d %-15s\n",
-				server.Name,
-				server.Host,
-				server.Port,
-				server.Username)
+		ungrouped := slices.DeleteFunc(slices.Clone(servers), func(s models.Server) bool {
+			return slices.ContainsFunc(s.Tags, func(tag string) bool { return !strings.Contains(tag, "=") })
+		})
+		if len(ungrouped) > 0 {
+			groupColor.Printf("\n未分组（%d 个服务器）", len(ungrouped))
+			printServerTable(ungrouped)
 		}
 		fmt.Println()
 	},
 }
 
+// printServerTable 以表格形式输出服务器列表
+func printServerTable(servers []models.Server) {
+	headerColor := color.New(color.FgCyan, color.Bold)
+	headerColor.Printf("\n%-20s %-20s %-8s %-15s %-10s %s\n", "名称", "主机", "端口", "用户名", "认证", "标签")
+	fmt.Println("──────────────────────────────────────────────────────────────────────────────────────")
+
+	for _, server := range servers {
+		fmt.Printf("%-20s %-20s %-8d %-15s %-10s %s\n",
+			server.Name,
+			server.Host,
+			server.Port,
+			server.Username,
+			describeAuth(server),
+			strings.Join(server.Tags, ","))
+		if listLong {
+			printServerDetails(&server)
+		}
+	}
+}
+
+// printServerDetails 输出服务器的详细配置，证书已过期或即将过期时给出警告
+func printServerDetails(server *models.Server) {
+	detailColor := color.New(color.FgHiBlack)
+	warnColor := color.New(color.FgYellow)
+	errColor := color.New(color.FgRed)
+
+	if server.IdentityFile != "" {
+		detailColor.Printf("  私钥: %s\n", server.IdentityFile)
+	}
+
+	cert, path, err := ssh.LoadCertificate(server)
+	switch {
+	case err != nil:
+		errColor.Printf("  ⚠ %v\n", err)
+	case cert != nil:
+		validity := "永久有效"
+		if expiry, ok := ssh.CertificateExpiry(cert); ok {
+			validity = "有效期至 " + expiry.Format("2006-01-02 15:04")
+		}
+		detailColor.Printf("  证书: %s（%s，允许的用户: %s，%s）\n", path, cert.KeyId, strings.Join(cert.ValidPrincipals, ","), validity)
+
+		if expiry, ok := ssh.CertificateExpiry(cert); ok {
+			now := time.Now()
+			if now.After(expiry) {
+				errColor.Printf("  ⚠ 证书已于 %s 过期\n", expiry.Format("2006-01-02 15:04"))
+			} else if ssh.CertificateExpiresSoon(cert, now) {
+				warnColor.Printf("  ⚠ 证书即将过期（剩余 %s）\n", formatDuration(expiry.Sub(now)))
+			}
+		}
+	}
+
+	if len(server.Jump) > 0 {
+		detailColor.Printf("  跳板机: %s\n", strings.Join(server.Jump, " -> "))
+	}
+	if server.Proxy != "" && server.Proxy != ssh.ProxyNone {
+		proxy := server.Proxy
+		if u, err := ssh.ParseProxy(proxy); err == nil {
+			proxy = u.Redacted()
+		}
+		detailColor.Printf("  代理: %s\n", proxy)
+	}
+	if server.HostCAFile != "" {
+		detailColor.Printf("  主机 CA: %s\n", server.HostCAFile)
+	}
+	if len(server.Tunnels) > 0 {
+		names := make([]string, len(server.Tunnels))
+		for i, t := range server.Tunnels {
+			names[i] = t.Name
+		}
+		detailColor.Printf("  隧道: %s\n", strings.Join(names, ", "))
+	}
+}
+
+// describeAuth 返回服务器使用的认证方式描述
+func describeAuth(server models.Server) string {
+	var methods []string
+	if server.IdentityFile != "" {
+		if path, err := ssh.CertificatePath(&server); err == nil && path != "" {
+			methods = append(methods, "证书")
+		} else {
+			methods = append(methods, "私钥")
+		}
+	}
+	if server.PasswordRef != "" {
+		scheme, _, _ := strings.Cut(server.PasswordRef, ":")
+		methods = append(methods, "密码("+scheme+")")
+	} else if server.Password != "" || len(methods) == 0 {
+		methods = append(methods, "密码")
+	}
+	return strings.Join(methods, "+")
+}
+
 func init() {
+	listCmd.Flags().BoolVarP(&listLong, "long", "l", false, "显示详细信息并检查证书有效期")
+	listCmd.Flags().BoolVarP(&listGroup, "group", "g", false, "按分组（@标签）显示")
 	rootCmd.AddCommand(listCmd)
 }
 
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"goSSH/internal/ssh"
+)
+
+var masterCmd = &cobra.Command{
+	Use:   "master",
+	Short: "管理连接复用的主连接进程",
+	Long: `查看和停止主连接进程。
+服务器配置了 control_master 后，goss exec、goss transfer 等命令会通过后台的主连接进程复用已认证的SSH连接，
+避免每次重新握手和认证。主连接进程在首次使用时自动启动，连接空闲超过 control_persist 秒后自动关闭。`,
+}
+
+var masterStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看主连接进程中的连接",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conns, running, err := ssh.MasterConnections()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		if !running {
+			fmt.Println("主连接进程未运行")
+			return
+		}
+		if len(conns) == 0 {
+			fmt.Println("主连接进程中没有连接")
+			return
+		}
+
+		headerColor := color.New(color.FgCyan, color.Bold)
+		headerColor.Printf("\n%-20s %-10s %-10s %-12s %-10s %-20s\n", "服务器", "客户端", "通道", "累计通道", "已连接", "状态")
+		fmt.Println("────────────────────────────────────────────────────────────────────────────────────")
+
+		for _, c := range conns {
+			state := "使用中"
+			if !c.IdleSince.IsZero() {
+				remaining := time.Duration(c.IdleTimeout)*time.Second - time.Since(c.IdleSince)
+				state = fmt.Sprintf("空闲，%s 后关闭", formatDuration(max(remaining, 0)))
+			}
+			fmt.Printf("%-20s %-10d %-10d %-12d %-10s %-20s\n",
+				c.Server, c.Clients, c.Channels, c.TotalChannels, formatDuration(time.Since(c.Since)), state)
+		}
+
+		if logPath, err := ssh.MasterLogPath(); err == nil {
+			fmt.Printf("\n日志: %s\n", logPath)
+		}
+		fmt.Println()
+	},
+}
+
+var masterStopCmd = &cobra.Command{
+	Use:   "stop [name]...",
+	Short: "关闭主连接进程中的连接",
+	Long:  "关闭指定服务器的连接，未提供参数时关闭所有连接并退出主连接进程。正在使用这些连接的命令会断开",
+	Run: func(cmd *cobra.Command, args []string) {
+		args, err := expandSelectors(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		stopped, running, err := ssh.StopMaster(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		if !running {
+			fmt.Println("主连接进程未运行")
+			return
+		}
+
+		successColor := color.New(color.FgGreen)
+		for _, name := range stopped {
+			successColor.Printf("✓ 已关闭与 %s 的连接\n", name)
+		}
+		for _, name := range args {
+			if !slices.Contains(stopped, name) {
+				fmt.Printf("主连接进程中没有与 %s 的连接\n", name)
+			}
+		}
+		if len(args) == 0 {
+			fmt.Println("主连接进程已退出")
+		}
+	},
+}
+
+var masterDaemonCmd = &cobra.Command{
+	Use:    "daemon",
+	Short:  "运行主连接进程（内部使用）",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := useDaemonKey(); err != nil {
+			os.Exit(1)
+		}
+
+		if err := ssh.RunMaster(); err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	masterCmd.AddCommand(masterStatusCmd)
+	masterCmd.AddCommand(masterStopCmd)
+	masterCmd.AddCommand(masterDaemonCmd)
+	rootCmd.AddCommand(masterCmd)
+}
@@ -9,10 +9,14 @@ import (
 	"goSSH/internal/config"
 )
 
+var (
+	removeYes bool // --yes 标志，跳过确认
+)
+
 var removeCmd = &cobra.Command{
 	Use:   "remove [name]",
 	Short: "删除SSH服务器配置",
-	Long:  "删除指定的SSH服务器配置，如果未提供名称则交互式选择",
+	Long:  "删除指定的SSH服务器配置，如果未提供名称则交互式选择。使用选择器时会先列出匹配的服务器再确认",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		manager, err := config.NewManager()
@@ -21,9 +25,23 @@ var removeCmd = &cobra.Command{
 			return
 		}
 
-		var name string
+		var names []string
 		if len(args) > 0 {
-			name = args[0]
+			servers, err := manager.Select(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
+			for _, s := range servers {
+				names = append(names, s.Name)
+			}
+
+			if len(servers) > 1 && !removeYes {
+				fmt.Printf("'%s' 匹配到 %d 个服务器:\n", args[0], len(servers))
+				for _, s := range servers {
+					fmt.Printf("  %s (%s@%s:%d)\n", s.Name, s.Username, s.Host, s.Port)
+				}
+			}
 		} else {
 			// 交互式选择服务器
 			servers, err := manager.ListServers()
@@ -53,31 +71,39 @@ var removeCmd = &cobra.Command{
 				return
 			}
 
-			name = servers[index].Name
+			names = []string{servers[index].Name}
 		}
 
 		// 确认删除
-		prompt := promptui.Prompt{
-			Label:     fmt.Sprintf("确认删除服务器 '%s'? (y/N)", name),
-			Default:   "N",
-			AllowEdit: true,
-		}
-		confirm, err := prompt.Run()
-		if err != nil || (confirm != "y" && confirm != "Y" && confirm != "yes" && confirm != "YES") {
-			fmt.Println("操作已取消")
-			return
+		if !removeYes {
+			label := fmt.Sprintf("确认删除服务器 '%s'? (y/N)", names[0])
+			if len(names) > 1 {
+				label = fmt.Sprintf("确认删除以上 %d 个服务器? (y/N)", len(names))
+			}
+			prompt := promptui.Prompt{
+				Label:     label,
+				Default:   "N",
+				AllowEdit: true,
+			}
+			confirm, err := prompt.Run()
+			if err != nil || (confirm != "y" && confirm != "Y" && confirm != "yes" && confirm != "YES") {
+				fmt.Println("操作已取消")
+				return
+			}
 		}
 
-		if err := manager.RemoveServer(name); err != nil {
-			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-			return
-		}
+		for _, name := range names {
+			if err := manager.RemoveServer(name); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return
+			}
 
-		fmt.Printf("✓ 服务器 '%s' 已删除\n", name)
+			fmt.Printf("✓ 服务器 '%s' 已删除\n", name)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(removeCmd)
+	removeCmd.Flags().BoolVarP(&removeYes, "yes", "y", false, "跳过确认")
 }
-
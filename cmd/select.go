@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
+	"goSSH/internal/config"
+	"goSSH/models"
+)
+
+// resolveServer 根据服务器名称或选择器（如 @web,env=prod）获取单个服务器
+// 选择器匹配到多个服务器时，在终端中交互式选择，非交互环境中返回错误
+func resolveServer(manager *config.Manager, arg, label string) (*models.Server, error) {
+	servers, err := manager.Select(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 1 {
+		return &servers[0], nil
+	}
+
+	if !isTerminal() {
+		return nil, fmt.Errorf("'%s' 匹配到 %d 个服务器（%s），请使用更精确的选择器", arg, len(servers), serverNames(servers))
+	}
+	return pickServer(servers, fmt.Sprintf("%s（'%s' 匹配到 %d 个服务器）", label, arg, len(servers)))
+}
+
+// resolveServers 根据多个服务器名称或选择器获取所有匹配的服务器，按配置顺序去重
+func resolveServers(manager *config.Manager, args []string) ([]models.Server, error) {
+	all, err := manager.ListServers()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, arg := range args {
+		servers, err := manager.Select(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range servers {
+			selected[server.Name] = true
+		}
+	}
+
+	var result []models.Server
+	for _, server := range all {
+		if selected[server.Name] {
+			result = append(result, server)
+		}
+	}
+	return result, nil
+}
+
+// expandSelectors 将参数中的选择器展开为匹配的服务器名称，普通名称保持不变（不要求服务器仍在配置中）
+func expandSelectors(args []string) ([]string, error) {
+	var names []string
+	var manager *config.Manager
+	for _, arg := range args {
+		if !config.IsSelector(arg) {
+			names = append(names, arg)
+			continue
+		}
+
+		if manager == nil {
+			var err error
+			if manager, err = config.NewManager(); err != nil {
+				return nil, err
+			}
+		}
+		servers, err := manager.Select(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range servers {
+			names = append(names, server.Name)
+		}
+	}
+	return names, nil
+}
+
+// pickServer 从服务器列表中交互式选择一个服务器
+func pickServer(servers []models.Server, label string) (*models.Server, error) {
+	items := make([]string, len(servers))
+	for i, s := range servers {
+		items[i] = fmt.Sprintf("%s (%s:%d - %s)", s.Name, s.Host, s.Port, s.Username)
+	}
+
+	prompt := promptui.Select{
+		Label:  label,
+		Items:  items,
+		Stdout: os.Stderr,
+	}
+
+	index, _, err := prompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	return &servers[index], nil
+}
+
+// serverNames 返回逗号分隔的服务器名称
+func serverNames(servers []models.Server) string {
+	names := make([]string, len(servers))
+	for i, s := range servers {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// isTerminal 判断标准输入是否为终端
+func isTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"goSSH/internal/config"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "管理服务器标签",
+	Long: `为服务器添加或删除标签。标签可以是分组名（如 web）或键值对（如 env=prod），
+命令中的服务器名称可以使用选择器按标签选择，例如 @web,env=prod 表示同时属于 web 分组且 env 为 prod 的服务器。`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <name> <tag>...",
+	Short: "为服务器添加标签",
+	Long:  "为服务器添加标签，名称可以是选择器，为所有匹配的服务器添加标签。键值对标签会替换同一个键的旧值",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateTags(args[0], args[1:], func(tags []string, tag string) []string {
+			// 同一个键只保留一个值
+			if key, _, ok := strings.Cut(tag, "="); ok {
+				tags = slices.DeleteFunc(tags, func(t string) bool { return strings.HasPrefix(t, key+"=") })
+			}
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+			return tags
+		})
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove <name> <tag>...",
+	Short: "删除服务器的标签",
+	Long:  "删除服务器的标签，名称可以是选择器。只提供键名（如 env）时删除该键的所有键值对标签",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateTags(args[0], args[1:], func(tags []string, tag string) []string {
+			return slices.DeleteFunc(tags, func(t string) bool {
+				return t == tag || (!strings.Contains(tag, "=") && strings.HasPrefix(t, tag+"="))
+			})
+		})
+	},
+}
+
+// updateTags 使用 apply 依次处理每个标签，更新所有匹配 arg 的服务器
+func updateTags(arg string, tags []string, apply func(tags []string, tag string) []string) {
+	for _, tag := range tags {
+		if err := config.ValidateTag(tag); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	manager, err := config.NewManager()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	servers, err := manager.Select(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, server := range servers {
+		updated := slices.Clone(server.Tags)
+		for _, tag := range tags {
+			updated = apply(updated, tag)
+		}
+		if slices.Equal(updated, server.Tags) {
+			fmt.Printf("'%s' 的标签没有变化\n", server.Name)
+			continue
+		}
+
+		if err := manager.SetTags(server.Name, updated); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ '%s' 的标签: %s\n", server.Name, describeTags(updated))
+	}
+}
+
+// describeTags 返回标签的显示文本
+func describeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "(无)"
+	}
+	return strings.Join(tags, ", ")
+}
+
+func init() {
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	rootCmd.AddCommand(tagCmd)
+}
@@ -106,7 +106,7 @@ var uploadCmd = &cobra.Command{
 		}
 
 		// 获取服务器配置
-		server, err := manager.GetServer(serverName)
+		server, err := resolveServer(manager, serverName, "选择服务器")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 			return
@@ -226,7 +226,7 @@ var downloadCmd = &cobra.Command{
 		}
 
 		// 获取服务器配置
-		server, err := manager.GetServer(serverName)
+		server, err := resolveServer(manager, serverName, "选择服务器")
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 			return
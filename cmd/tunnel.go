@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"goSSH/internal/config"
+	"goSSH/internal/ssh"
+	"goSSH/internal/storage"
+	"goSSH/internal/tunnel"
+)
+
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel",
+	Short: "管理保存的隧道",
+	Long: `启动、停止和查看服务器配置中保存的隧道（tunnels）。
+隧道在后台进程中运行，SSH连接断开后会自动重连，多个 goss 命令可以查看同一组隧道的状态。`,
+}
+
+var tunnelUpCmd = &cobra.Command{
+	Use:   "up [server[/tunnel]]...",
+	Short: "在后台启动隧道",
+	Long: `在后台启动隧道。参数为服务器名称（启动该服务器的所有隧道）或 服务器/隧道名称。
+未提供参数时启动所有配置了 autostart 的隧道。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := tunnelTargets(args, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		if len(targets) == 0 {
+			fmt.Println("没有配置 autostart 的隧道，请指定要启动的隧道")
+			return
+		}
+
+		results, err := tunnel.Up(targets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		if !printTunnelResults(results, "已启动") {
+			os.Exit(1)
+		}
+	},
+}
+
+var tunnelDownCmd = &cobra.Command{
+	Use:   "down [server[/tunnel]]...",
+	Short: "停止隧道",
+	Long:  "停止指定的隧道，未提供参数时停止所有隧道。所有隧道都停止后，后台进程会自动退出",
+	Run: func(cmd *cobra.Command, args []string) {
+		var targets []tunnel.Target
+		if len(args) > 0 {
+			var err error
+			targets, err = tunnelTargets(args, false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		results, running, err := tunnel.Down(targets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		if !running {
+			fmt.Println("没有运行中的隧道")
+			return
+		}
+
+		if !printTunnelResults(results, "已停止") {
+			os.Exit(1)
+		}
+	},
+}
+
+var tunnelStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看隧道状态和流量统计",
+	Long:  "列出所有保存的隧道及其运行状态、连接数和流量统计",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, err := config.NewManager()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		servers, err := manager.ListServers()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		running, _, err := tunnel.Running()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		// 以配置中的隧道为准，合并运行状态；已从配置中删除但仍在运行的隧道也会列出
+		statuses := make(map[tunnel.Target]tunnel.Status)
+		for _, st := range running {
+			statuses[st.Target] = st
+		}
+
+		var list []tunnel.Status
+		for _, server := range servers {
+			for _, t := range server.Tunnels {
+				target := tunnel.Target{Server: server.Name, Tunnel: t.Name}
+				st, ok := statuses[target]
+				if !ok {
+					st = tunnel.Status{Target: target, Type: t.Type, State: tunnel.StateStopped}
+					if spec, err := tunnel.Spec(t); err == nil {
+						st.Spec = spec.String()
+					} else {
+						st.Error = err.Error()
+					}
+				}
+				delete(statuses, target)
+				list = append(list, st)
+			}
+		}
+		for _, st := range running {
+			if _, ok := statuses[st.Target]; ok {
+				list = append(list, st)
+			}
+		}
+
+		if len(list) == 0 {
+			fmt.Println("没有配置任何隧道")
+			return
+		}
+
+		headerColor := color.New(color.FgCyan, color.Bold)
+		headerColor.Printf("\n%-24s %-8s %-40s %-14s %-10s %-12s %-12s\n", "隧道", "类型", "规则", "状态", "连接", "发送", "接收")
+		fmt.Println("──────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+
+		for _, st := range list {
+			fmt.Printf("%-24s %-8s %-40s %-14s %-10s %-12s %-12s\n",
+				st.Target, st.Type, st.Spec, describeTunnelState(st),
+				fmt.Sprintf("%d/%d", st.Active, st.Connections),
+				ssh.FormatBytes(st.BytesSent), ssh.FormatBytes(st.BytesReceived))
+			if st.Error != "" {
+				color.New(color.FgRed).Printf("  └ %s\n", st.Error)
+			}
+		}
+
+		if logPath, err := tunnel.LogPath(); err == nil && len(running) > 0 {
+			fmt.Printf("\n连接日志: %s\n", logPath)
+		}
+		fmt.Println()
+	},
+}
+
+var tunnelDaemonCmd = &cobra.Command{
+	Use:    "daemon",
+	Short:  "运行隧道后台进程（内部使用）",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := useDaemonKey(); err != nil {
+			os.Exit(1)
+		}
+
+		if err := tunnel.RunDaemon(); err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+// useDaemonKey 读取启动后台进程时通过标准输入传入的已解锁主密码密钥（未启用主密码模式时为空行）
+func useDaemonKey() error {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	encoded := strings.TrimSpace(line)
+	if encoded == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+
+	st, err := storage.NewStorage()
+	if err != nil {
+		return err
+	}
+	return st.UseKey(key)
+}
+
+// tunnelTargets 将命令行参数解析为隧道列表
+// 参数为服务器名称时表示该服务器的所有隧道；未提供参数且 autostart 为 true 时返回所有自动启动的隧道
+func tunnelTargets(args []string, autostart bool) ([]tunnel.Target, error) {
+	manager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []tunnel.Target
+	if len(args) == 0 && autostart {
+		servers, err := manager.ListServers()
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range servers {
+			for _, t := range server.Tunnels {
+				if t.Autostart {
+					targets = append(targets, tunnel.Target{Server: server.Name, Tunnel: t.Name})
+				}
+			}
+		}
+		return targets, nil
+	}
+
+	for _, arg := range args {
+		serverArg, tunnelName, hasTunnel := strings.Cut(arg, "/")
+
+		// 服务器部分可以是选择器，匹配到多个服务器时只要求至少一个服务器有对应的隧道
+		servers, err := manager.Select(serverArg)
+		if err != nil {
+			return nil, err
+		}
+
+		before := len(targets)
+		for _, server := range servers {
+			for _, t := range server.Tunnels {
+				if !hasTunnel || t.Name == tunnelName {
+					targets = append(targets, tunnel.Target{Server: server.Name, Tunnel: t.Name})
+				}
+			}
+		}
+		if len(targets) > before {
+			continue
+		}
+
+		switch {
+		case !hasTunnel && len(servers) == 1:
+			return nil, fmt.Errorf("服务器 '%s' 没有配置隧道", servers[0].Name)
+		case !hasTunnel:
+			return nil, fmt.Errorf("'%s' 匹配的服务器都没有配置隧道", serverArg)
+		case len(servers) == 1:
+			return nil, fmt.Errorf("服务器 '%s' 没有名为 '%s' 的隧道", servers[0].Name, tunnelName)
+		default:
+			return nil, fmt.Errorf("'%s' 匹配的服务器都没有名为 '%s' 的隧道", serverArg, tunnelName)
+		}
+	}
+	return targets, nil
+}
+
+// printTunnelResults 输出操作结果，全部成功时返回 true
+func printTunnelResults(results []tunnel.Result, action string) bool {
+	successColor := color.New(color.FgGreen)
+	errColor := color.New(color.FgRed)
+
+	ok := true
+	for _, r := range results {
+		if r.Error != "" {
+			errColor.Printf("✗ %s: %s\n", r.Target, r.Error)
+			ok = false
+			continue
+		}
+		successColor.Printf("✓ %s %s\n", r.Target, action)
+	}
+	return ok
+}
+
+// describeTunnelState 返回隧道状态的描述
+func describeTunnelState(st tunnel.Status) string {
+	switch st.State {
+	case tunnel.StateRunning:
+		return "运行 " + formatDuration(time.Since(st.Since))
+	case tunnel.StateReconnecting:
+		return "重连中"
+	case tunnel.StateFailed:
+		return "失败"
+	default:
+		return "未运行"
+	}
+}
+
+// formatDuration 将时长格式化为简短形式，如 3d4h、2h5m、45s
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+func init() {
+	tunnelCmd.AddCommand(tunnelUpCmd)
+	tunnelCmd.AddCommand(tunnelDownCmd)
+	tunnelCmd.AddCommand(tunnelStatusCmd)
+	tunnelCmd.AddCommand(tunnelDaemonCmd)
+	rootCmd.AddCommand(tunnelCmd)
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"goSSH/internal/daemon"
+	"goSSH/internal/ssh"
+	"goSSH/internal/storage"
+)
+
+var (
+	vaultTTL time.Duration // --ttl 标志，解锁有效期
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "管理主密码加密存储",
+	Long: `管理配置文件的主密码模式。
+启用后，配置文件中的密码和私钥口令使用由主密码派生的密钥（scrypt + AES-GCM）加密存储，
+每次运行时解锁一次，也可以使用 'goss vault unlock' 在一段时间内免输入主密码。
+非交互环境中可以通过 GOSS_MASTER_PASSWORD 环境变量提供主密码。`,
+}
+
+var vaultInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "启用主密码模式并加密已有密码",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := storage.NewStorage()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		if enabled, err := st.VaultEnabled(); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		} else if enabled {
+			fmt.Println("已启用主密码模式，如需更换主密码请使用 'goss vault rekey'")
+			return
+		}
+
+		password, err := promptNewMasterPassword()
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		count, err := st.InitVault(password)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ 已启用主密码模式，加密了 %d 个敏感字段\n", count)
+		fmt.Println("请牢记主密码，遗忘后将无法恢复已保存的密码")
+	},
+}
+
+var vaultUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "解锁并在一段时间内免输入主密码",
+	Long:  "验证主密码后启动后台解锁进程，在 --ttl 指定的时间内，本机的 goss 命令无需再输入主密码",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := storage.NewStorage()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		vault, err := st.Vault()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+		if vault == nil {
+			fmt.Println("未启用主密码模式，请先执行 'goss vault init'")
+			return
+		}
+
+		password, err := storage.PromptMasterPassword("主密码")
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		key, err := storage.UnlockKey(vault, password)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 替换已在运行的解锁进程
+		storage.LockVaultAgent()
+
+		path, err := storage.VaultSocketPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		input := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+		if err := daemon.Start(input, "vault", "agent", "--ttl", vaultTTL.String()); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := daemon.WaitRunning(path, 5*time.Second); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ 已解锁，有效期 %s\n", vaultTTL)
+	},
+}
+
+var vaultLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "立即锁定（停止后台解锁进程）",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		locked := storage.LockVaultAgent()
+		masterStopped := stopMasterForVault()
+		if !locked && !masterStopped {
+			fmt.Println("当前未解锁")
+			return
+		}
+		fmt.Println("✓ 已锁定")
+	},
+}
+
+var vaultRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "更换主密码",
+	Long:  "验证当前主密码后设置新的主密码，并使用新密钥重新加密所有敏感字段",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := storage.NewStorage()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		vault, err := st.Vault()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+		if vault == nil {
+			fmt.Println("未启用主密码模式，请先执行 'goss vault init'")
+			return
+		}
+
+		password, err := storage.PromptMasterPassword("当前主密码")
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+		if _, err := storage.UnlockKey(vault, password); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		newPassword, err := promptNewMasterPassword()
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+
+		if err := st.Rekey(newPassword); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 旧密钥已失效
+		storage.LockVaultAgent()
+		stopMasterForVault()
+
+		fmt.Println("✓ 主密码已更换")
+	},
+}
+
+// stopMasterForVault 退出主连接进程，它在启动时取得了主密码密钥，锁定或更换主密码后不应继续使用
+// 返回主连接进程是否在运行
+func stopMasterForVault() bool {
+	_, running, err := ssh.StopMaster(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 退出主连接进程失败: %v\n", err)
+	}
+	return running
+}
+
+var vaultStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看主密码模式状态",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		st, err := storage.NewStorage()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+
+		enabled, err := st.VaultEnabled()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return
+		}
+		if !enabled {
+			fmt.Println("主密码模式: 未启用")
+			return
+		}
+
+		fmt.Println("主密码模式: 已启用")
+		if remaining, ok := storage.VaultAgentStatus(); ok {
+			fmt.Printf("解锁状态:   已解锁（剩余 %s）\n", remaining)
+		} else {
+			fmt.Println("解锁状态:   已锁定")
+		}
+	},
+}
+
+// vaultAgentCmd 后台解锁进程，由 'goss vault unlock' 启动，从标准输入读取密钥
+var vaultAgentCmd = &cobra.Command{
+	Use:    "agent",
+	Short:  "运行后台解锁进程（内部使用）",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			os.Exit(1)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(line))
+		if err != nil {
+			os.Exit(1)
+		}
+
+		if err := storage.RunVaultAgent(key, vaultTTL); err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+// promptNewMasterPassword 交互式设置新的主密码（输入两次确认）
+func promptNewMasterPassword() (string, error) {
+	password, err := storage.PromptMasterPassword("新主密码")
+	if err != nil {
+		return "", err
+	}
+
+	confirm, err := storage.PromptMasterPassword("再次输入新主密码")
+	if err != nil {
+		return "", err
+	}
+
+	if password != confirm {
+		return "", fmt.Errorf("两次输入的主密码不一致")
+	}
+	return password, nil
+}
+
+func init() {
+	vaultUnlockCmd.Flags().DurationVar(&vaultTTL, "ttl", 15*time.Minute, "解锁有效期")
+	vaultAgentCmd.Flags().DurationVar(&vaultTTL, "ttl", 15*time.Minute, "解锁有效期")
+
+	vaultCmd.AddCommand(vaultInitCmd)
+	vaultCmd.AddCommand(vaultUnlockCmd)
+	vaultCmd.AddCommand(vaultLockCmd)
+	vaultCmd.AddCommand(vaultRekeyCmd)
+	vaultCmd.AddCommand(vaultStatusCmd)
+	vaultCmd.AddCommand(vaultAgentCmd)
+	rootCmd.AddCommand(vaultCmd)
+}
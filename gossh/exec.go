@@ -0,0 +1,52 @@
+// Package gossh 供其他 Go 程序使用 GoSSH 已配置的服务器批量执行命令
+package gossh
+
+import (
+	"goSSH/internal/config"
+	"goSSH/internal/ssh"
+	"goSSH/models"
+)
+
+// ExecRecord 表示在一个服务器上执行命令的结构化结果，与 goss exec -o json 输出的记录相同
+type ExecRecord = ssh.ExecRecord
+
+// ParallelOptions 是在多个服务器上执行命令的选项
+type ParallelOptions = ssh.ParallelOptions
+
+// SignalRelay 将本地收到的信号转发给正在执行的远程命令
+type SignalRelay = ssh.SignalRelay
+
+// NewSignalRelay 开始接管本地的 SIGINT、SIGTERM 和 SIGHUP 信号，使用完毕后需要调用 Stop 恢复默认处理
+func NewSignalRelay() *SignalRelay {
+	return ssh.NewSignalRelay()
+}
+
+// Select 根据服务器名称或选择器（如 @web、env=prod）返回已配置的匹配服务器
+func Select(target string) ([]models.Server, error) {
+	manager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.Select(target)
+}
+
+// CollectParallel 在多个服务器上并发执行命令并收集输出，返回每个服务器的结构化结果（与 servers 的顺序一致）
+// done 不为 nil 时，每个服务器执行结束后立即以其结果调用 done，多次调用不会同时进行
+func CollectParallel(servers []models.Server, command string, opts ParallelOptions, done func(record ExecRecord)) []ExecRecord {
+	return ssh.CollectParallel(servers, command, opts, done)
+}
+
+// Exec 在服务器名称或选择器匹配的所有服务器上执行命令，相当于 Select 之后调用 CollectParallel
+func Exec(target, command string, opts ParallelOptions) ([]ExecRecord, error) {
+	servers, err := Select(target)
+	if err != nil {
+		return nil, err
+	}
+	return CollectParallel(servers, command, opts, nil), nil
+}
+
+// ExitCode 返回与 goss exec 相同的退出码：远程命令的退出码，超时为 124，中断为 130，
+// 主机密钥校验失败为 253，认证失败为 254，其他连接错误为 255，err 为 nil 时为 0
+func ExitCode(err error) int {
+	return ssh.ExitCode(err)
+}
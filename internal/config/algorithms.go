@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// algorithmList 表示一类可配置的算法
+type algorithmList struct {
+	field      string   // 配置字段名
+	configured []string // 配置的算法列表
+	supported  []string // x/crypto 支持且默认启用的算法
+	insecure   []string // x/crypto 支持但存在安全问题、默认不启用的算法
+}
+
+// algorithmLists 返回服务器配置中的各类算法
+func algorithmLists(server *models.Server) (ciphers, kex, macs, hostKeys algorithmList) {
+	supported := ssh.SupportedAlgorithms()
+	insecure := ssh.InsecureAlgorithms()
+
+	ciphers = algorithmList{"ciphers", server.Ciphers, supported.Ciphers, insecure.Ciphers}
+	kex = algorithmList{"key_exchanges", server.KeyExchanges, supported.KeyExchanges, insecure.KeyExchanges}
+	macs = algorithmList{"macs", server.MACs, supported.MACs, insecure.MACs}
+	hostKeys = algorithmList{"host_key_algorithms", server.HostKeyAlgorithms, supported.HostKeys, insecure.HostKeys}
+	return
+}
+
+// resolve 校验配置的算法名称并返回最终使用的算法列表，未配置时返回 nil（使用默认值）
+// 以 + 开头的名称追加到列表末尾，以 - 开头的名称从列表中移除；只有 +/- 项时以默认启用的算法为基础
+func (l algorithmList) resolve() ([]string, error) {
+	if len(l.configured) == 0 {
+		return nil, nil
+	}
+
+	var result []string
+	hasPlain := slices.ContainsFunc(l.configured, func(name string) bool {
+		return !strings.HasPrefix(name, "+") && !strings.HasPrefix(name, "-")
+	})
+	if !hasPlain {
+		result = slices.Clone(l.supported)
+	}
+
+	for _, entry := range l.configured {
+		name := strings.TrimLeft(entry, "+-")
+		if !slices.Contains(l.supported, name) && !slices.Contains(l.insecure, name) {
+			return nil, fmt.Errorf("%s 中的算法 '%s' 不受支持，可用: %s", l.field, name, strings.Join(append(slices.Clone(l.supported), l.insecure...), ", "))
+		}
+
+		if strings.HasPrefix(entry, "-") {
+			result = slices.DeleteFunc(result, func(algo string) bool { return algo == name })
+		} else if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%s 配置后没有可用的算法", l.field)
+	}
+	return result, nil
+}
+
+// ValidateAlgorithms 校验服务器配置的算法名称是否受支持
+func ValidateAlgorithms(server *models.Server) error {
+	_, _, err := ResolveAlgorithms(server)
+	return err
+}
+
+// ResolveAlgorithms 根据服务器配置返回加密、密钥交换、MAC 算法设置和主机密钥算法列表
+// 未配置的类别为 nil，使用 x/crypto 的默认值
+// 只校验这一个服务器的配置，其他服务器的错误配置不影响它的连接
+func ResolveAlgorithms(server *models.Server) (ssh.Config, []string, error) {
+	config, hostKeys, err := resolveAlgorithms(server)
+	if err != nil {
+		return ssh.Config{}, nil, fmt.Errorf("服务器 '%s' 的算法配置无效: %v", server.Name, err)
+	}
+	return config, hostKeys, nil
+}
+
+// resolveAlgorithms 依次解析各类算法配置
+func resolveAlgorithms(server *models.Server) (ssh.Config, []string, error) {
+	cipherList, kexList, macList, hostKeyList := algorithmLists(server)
+
+	var config ssh.Config
+	var err error
+	if config.Ciphers, err = cipherList.resolve(); err != nil {
+		return ssh.Config{}, nil, err
+	}
+	if config.KeyExchanges, err = kexList.resolve(); err != nil {
+		return ssh.Config{}, nil, err
+	}
+	if config.MACs, err = macList.resolve(); err != nil {
+		return ssh.Config{}, nil, err
+	}
+
+	hostKeys, err := hostKeyList.resolve()
+	if err != nil {
+		return ssh.Config{}, nil, err
+	}
+	return config, hostKeys, nil
+}
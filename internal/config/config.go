@@ -28,6 +28,10 @@ func (m *Manager) AddServer(server models.Server) error {
 		return err
 	}
 
+	if err := ValidateAlgorithms(&server); err != nil {
+		return err
+	}
+
 	// 检查是否已存在同名服务器
 	for _, s := range config.Servers {
 		if s.Name == server.Name {
@@ -70,15 +74,31 @@ func (m *Manager) RemoveServer(name string) error {
 }
 
 // ListServers 列出所有服务器
+// 返回的服务器配置已合并全局设置中的默认值
 func (m *Manager) ListServers() ([]models.Server, error) {
 	config, err := m.storage.Load()
 	if err != nil {
 		return nil, err
 	}
+
+	for i := range config.Servers {
+		applyDefaults(&config.Servers[i], config.Settings)
+	}
+	return config.Servers, nil
+}
+
+// StoredServers 返回配置文件中保存的服务器，不合并全局设置中的默认值
+// 用于修改后再通过 UpdateServer 保存，避免将全局设置写入服务器配置
+func (m *Manager) StoredServers() ([]models.Server, error) {
+	config, err := m.storage.Load()
+	if err != nil {
+		return nil, err
+	}
 	return config.Servers, nil
 }
 
 // GetServer 根据名称获取服务器
+// 返回的服务器配置已合并全局设置中的默认值
 func (m *Manager) GetServer(name string) (*models.Server, error) {
 	config, err := m.storage.Load()
 	if err != nil {
@@ -87,6 +107,7 @@ func (m *Manager) GetServer(name string) (*models.Server, error) {
 
 	for _, s := range config.Servers {
 		if s.Name == name {
+			applyDefaults(&s, config.Settings)
 			return &s, nil
 		}
 	}
@@ -94,8 +115,64 @@ func (m *Manager) GetServer(name string) (*models.Server, error) {
 	return nil, fmt.Errorf("服务器 '%s' 不存在", name)
 }
 
+// GetSettings 获取全局设置
+func (m *Manager) GetSettings() (models.Settings, error) {
+	config, err := m.storage.Load()
+	if err != nil {
+		return models.Settings{}, err
+	}
+	return config.Settings, nil
+}
+
+// applyDefaults 将全局设置作为默认值填充到服务器配置中
+// 服务器自身已配置的字段优先
+func applyDefaults(server *models.Server, settings models.Settings) {
+	if server.StrictHostKeyChecking == "" {
+		server.StrictHostKeyChecking = settings.StrictHostKeyChecking
+	}
+	if server.UseSystemKnownHosts == nil {
+		server.UseSystemKnownHosts = &settings.UseSystemKnownHosts
+	}
+	if server.HostCAFile == "" {
+		server.HostCAFile = settings.HostCAFile
+	}
+	if server.KeepaliveInterval == 0 {
+		server.KeepaliveInterval = settings.KeepaliveInterval
+	}
+	if server.KeepaliveCountMax == 0 {
+		server.KeepaliveCountMax = settings.KeepaliveCountMax
+	}
+	if server.ControlMaster == nil {
+		server.ControlMaster = &settings.ControlMaster
+	}
+	if server.ControlPersist == 0 {
+		server.ControlPersist = settings.ControlPersist
+	}
+	if len(server.Ciphers) == 0 {
+		server.Ciphers = settings.Ciphers
+	}
+	if len(server.KeyExchanges) == 0 {
+		server.KeyExchanges = settings.KeyExchanges
+	}
+	if len(server.MACs) == 0 {
+		server.MACs = settings.MACs
+	}
+	if len(server.HostKeyAlgorithms) == 0 {
+		server.HostKeyAlgorithms = settings.HostKeyAlgorithms
+	}
+	// 代理地址和凭据作为一个整体继承，避免服务器自身的代理使用全局代理的密码
+	if server.Proxy == "" {
+		server.Proxy = settings.Proxy
+		server.ProxyPasswordRef = settings.ProxyPasswordRef
+	}
+}
+
 // UpdateServer 更新服务器信息
 func (m *Manager) UpdateServer(server models.Server) error {
+	if err := ValidateAlgorithms(&server); err != nil {
+		return err
+	}
+
 	config, err := m.storage.Load()
 	if err != nil {
 		return err
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"goSSH/models"
+)
+
+// Selector 表示服务器选择器，由逗号分隔的条件组成，服务器满足所有条件时匹配
+//
+// 支持的条件：
+//   - @web：带有分组标签 web
+//   - env=prod：带有标签 env=prod，值可以使用 * 和 ? 通配符
+//   - env!=prod：没有标签 env=prod
+//   - web-*：名称匹配通配符（不含通配符时要求名称完全相同）
+type Selector struct {
+	raw   string
+	terms []selectorTerm
+}
+
+// selectorTerm 表示选择器中的一个条件
+type selectorTerm struct {
+	group   string // @group 条件的分组名
+	key     string // key=value 条件的键
+	value   string // key=value 条件的值（通配符）
+	negate  bool   // key!=value 条件
+	pattern string // 名称通配符
+}
+
+// IsSelector 判断参数是否为选择器（而不是单个服务器名称）
+func IsSelector(arg string) bool {
+	return strings.HasPrefix(arg, "@") || strings.ContainsAny(arg, ",=*?")
+}
+
+// ParseSelector 解析选择器表达式
+func ParseSelector(expr string) (*Selector, error) {
+	selector := &Selector{raw: expr}
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("选择器 '%s' 格式错误: 存在空条件", expr)
+		}
+
+		var term selectorTerm
+		switch {
+		case strings.HasPrefix(part, "@"):
+			term.group = part[1:]
+			if term.group == "" {
+				return nil, fmt.Errorf("选择器 '%s' 格式错误: @ 后缺少分组名", expr)
+			}
+		case strings.Contains(part, "!="):
+			term.key, term.value, _ = strings.Cut(part, "!=")
+			term.negate = true
+		case strings.Contains(part, "="):
+			term.key, term.value, _ = strings.Cut(part, "=")
+		default:
+			term.pattern = part
+		}
+
+		if term.group == "" && term.pattern == "" && term.key == "" {
+			return nil, fmt.Errorf("选择器 '%s' 格式错误: '%s' 缺少标签名", expr, part)
+		}
+		for _, p := range []string{term.value, term.pattern} {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("选择器 '%s' 中的通配符 '%s' 无效", expr, p)
+			}
+		}
+		selector.terms = append(selector.terms, term)
+	}
+	return selector, nil
+}
+
+// String 返回选择器表达式
+func (s *Selector) String() string {
+	return s.raw
+}
+
+// Match 判断服务器是否满足选择器的所有条件
+func (s *Selector) Match(server *models.Server) bool {
+	for _, term := range s.terms {
+		if !term.match(server) {
+			return false
+		}
+	}
+	return true
+}
+
+// match 判断服务器是否满足单个条件
+func (t selectorTerm) match(server *models.Server) bool {
+	switch {
+	case t.group != "":
+		return slices.Contains(server.Tags, t.group)
+	case t.key != "":
+		found := false
+		for _, tag := range server.Tags {
+			key, value, ok := strings.Cut(tag, "=")
+			if ok && key == t.key {
+				if matched, _ := path.Match(t.value, value); matched {
+					found = true
+					break
+				}
+			}
+		}
+		return found != t.negate
+	default:
+		matched, _ := path.Match(t.pattern, server.Name)
+		return matched
+	}
+}
+
+// ValidateTag 校验标签格式，标签为分组名（如 web）或键值对（如 env=prod）
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("标签不能为空")
+	}
+	if strings.ContainsAny(tag, ", \t@!*?") {
+		return fmt.Errorf("标签 '%s' 不能包含逗号、空白或 @ ! * ? 字符", tag)
+	}
+	if key, _, ok := strings.Cut(tag, "="); ok && key == "" {
+		return fmt.Errorf("标签 '%s' 缺少键名", tag)
+	}
+	return nil
+}
+
+// Groups 返回服务器中出现的所有分组（不含 = 的标签），按名称排序
+func Groups(servers []models.Server) []string {
+	var groups []string
+	for _, server := range servers {
+		for _, tag := range server.Tags {
+			if !strings.Contains(tag, "=") && !slices.Contains(groups, tag) {
+				groups = append(groups, tag)
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Filter 返回满足选择器的服务器
+func Filter(servers []models.Server, selector *Selector) []models.Server {
+	var result []models.Server
+	for _, server := range servers {
+		if selector.Match(&server) {
+			result = append(result, server)
+		}
+	}
+	return result
+}
+
+// Select 根据服务器名称或选择器返回匹配的服务器（按配置顺序）
+// 参数与已配置的服务器名称完全相同时优先作为名称处理；没有匹配的服务器时返回错误
+func (m *Manager) Select(arg string) ([]models.Server, error) {
+	servers, err := m.ListServers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		if server.Name == arg {
+			return []models.Server{server}, nil
+		}
+	}
+	if !IsSelector(arg) {
+		return nil, fmt.Errorf("服务器 '%s' 不存在", arg)
+	}
+
+	selector, err := ParseSelector(arg)
+	if err != nil {
+		return nil, err
+	}
+	matched := Filter(servers, selector)
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("没有匹配 '%s' 的服务器", arg)
+	}
+	return matched, nil
+}
+
+// SetTags 设置服务器的标签
+func (m *Manager) SetTags(name string, tags []string) error {
+	for _, tag := range tags {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	config, err := m.storage.Load()
+	if err != nil {
+		return err
+	}
+
+	for i := range config.Servers {
+		if config.Servers[i].Name == name {
+			config.Servers[i].Tags = tags
+			return m.storage.Save(config)
+		}
+	}
+	return fmt.Errorf("服务器 '%s' 不存在", name)
+}
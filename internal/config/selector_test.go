@@ -0,0 +1,100 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"goSSH/models"
+)
+
+func TestSelector(t *testing.T) {
+	servers := []models.Server{
+		{Name: "web-1", Tags: []string{"web", "env=prod", "region=us-east"}},
+		{Name: "web-2", Tags: []string{"web", "env=staging"}},
+		{Name: "db-1", Tags: []string{"db", "env=prod"}},
+		{Name: "bastion"},
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"@web", []string{"web-1", "web-2"}},
+		{"env=prod", []string{"web-1", "db-1"}},
+		{"env!=prod", []string{"web-2", "bastion"}},
+		{"@web,env=prod", []string{"web-1"}},
+		{"region=us-*", []string{"web-1"}},
+		{"web-*", []string{"web-1", "web-2"}},
+		{"db-?", []string{"db-1"}},
+		{"@web, env!=staging", []string{"web-1"}},
+		{"@cache", nil},
+		{"bastion,@web", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			selector, err := ParseSelector(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSelector 返回错误: %v", err)
+			}
+			var got []string
+			for _, s := range Filter(servers, selector) {
+				got = append(got, s.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("匹配 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, expr := range []string{"", "@", "@web,", "=prod", "!=prod", "web-[", "env=[a"} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseSelector(expr); err == nil {
+				t.Fatalf("ParseSelector(%q) 应返回错误", expr)
+			}
+		})
+	}
+}
+
+func TestIsSelector(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"web-1", false},
+		{"admin@gw", false},
+		{"@web", true},
+		{"env=prod", true},
+		{"web-*", true},
+		{"a,b", true},
+	}
+	for _, tt := range tests {
+		if got := IsSelector(tt.arg); got != tt.want {
+			t.Errorf("IsSelector(%q) = %v，应为 %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		wantErr bool
+	}{
+		{"web", false},
+		{"env=prod", false},
+		{"env=", false},
+		{"", true},
+		{"=prod", true},
+		{"a,b", true},
+		{"a b", true},
+		{"@web", true},
+		{"web*", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateTag(tt.tag); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateTag(%q) = %v，是否应返回错误: %v", tt.tag, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Start 以后台进程方式启动当前程序，并与当前终端分离
+// stdin 中的数据会写入子进程的标准输入（用于传递不宜出现在命令行参数中的敏感数据）
+func Start(stdin []byte, args ...string) error {
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取可执行文件路径失败: %v", err)
+	}
+
+	cmd := exec.Command(execPath, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动后台进程失败: %v", err)
+	}
+
+	// 不等待子进程退出，释放相关资源
+	return cmd.Process.Release()
+}
+
+// Listen 在指定路径上监听 UNIX 套接字，仅当前用户可访问
+// 如果套接字文件已存在但没有进程在监听，则视为残留文件并删除
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if IsRunning(path) {
+			return nil, fmt.Errorf("后台进程已在运行: %s", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("监听套接字失败: %v", err)
+	}
+
+	if err := restrictSocket(path); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("设置套接字权限失败: %v", err)
+	}
+
+	return listener, nil
+}
+
+// Dial 连接指定路径的 UNIX 套接字
+func Dial(path string) (net.Conn, error) {
+	return net.DialTimeout("unix", path, 2*time.Second)
+}
+
+// IsRunning 判断指定套接字上是否有后台进程在监听
+func IsRunning(path string) bool {
+	conn, err := Dial(path)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// WaitRunning 等待后台进程开始监听，超时返回错误
+func WaitRunning(path string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if IsRunning(path) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("等待后台进程启动超时")
+}
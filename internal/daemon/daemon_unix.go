@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachedProcAttr 返回使子进程脱离当前会话的进程属性（Unix系统）
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// restrictSocket 将套接字文件权限设为 0600，只允许当前用户连接
+// 套接字位于权限为 0700 的配置目录中，设置权限之前其他用户同样无法连接
+func restrictSocket(path string) error {
+	return os.Chmod(path, 0600)
+}
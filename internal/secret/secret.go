@@ -0,0 +1,171 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/zalando/go-keyring"
+)
+
+// SecretProvider 从外部来源获取密码等敏感信息
+type SecretProvider interface {
+	// Get 根据引用（不含前缀）获取敏感信息
+	Get(ref string) (string, error)
+}
+
+// providers 已注册的提供者，键为引用前缀
+var providers = map[string]SecretProvider{
+	"env":     envProvider{},
+	"file":    fileProvider{},
+	"cmd":     commandProvider{},
+	"pass":    passProvider{},
+	"keyring": keyringProvider{},
+}
+
+// Register 注册自定义的提供者
+func Register(scheme string, provider SecretProvider) {
+	providers[scheme] = provider
+}
+
+// Schemes 返回所有支持的引用前缀
+func Schemes() []string {
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// parseRef 将引用拆分为前缀和内容，如 "env:PROD_PW" -> ("env", "PROD_PW")
+func parseRef(ref string) (SecretProvider, string, error) {
+	scheme, value, ok := strings.Cut(ref, ":")
+	if !ok || value == "" {
+		return nil, "", fmt.Errorf("无效的密码引用 '%s'，格式应为 <类型>:<内容>", ref)
+	}
+
+	provider, ok := providers[scheme]
+	if !ok {
+		return nil, "", fmt.Errorf("不支持的密码引用类型 '%s'（可选: %s）", scheme, strings.Join(Schemes(), ", "))
+	}
+	return provider, value, nil
+}
+
+// Validate 检查引用格式是否正确（不实际获取内容）
+func Validate(ref string) error {
+	_, _, err := parseRef(ref)
+	return err
+}
+
+// Resolve 根据引用获取敏感信息
+func Resolve(ref string) (string, error) {
+	provider, value, err := parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := provider.Get(value)
+	if err != nil {
+		return "", fmt.Errorf("获取密码引用 '%s' 失败: %v", ref, err)
+	}
+	return secret, nil
+}
+
+// envProvider 从环境变量读取，如 env:PROD_PW
+type envProvider struct{}
+
+func (envProvider) Get(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("环境变量 %s 未设置", name)
+	}
+	return value, nil
+}
+
+// fileProvider 从文件读取（去除末尾换行），如 file:~/.secrets/prod
+type fileProvider struct{}
+
+func (fileProvider) Get(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// commandProvider 执行外部命令并使用其输出（去除末尾换行），如 cmd:pass show prod/db
+type commandProvider struct{}
+
+func (commandProvider) Get(command string) (string, error) {
+	output, err := runCommand(command)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(output, "\r\n"), nil
+}
+
+// passProvider 从 pass 密码管理器读取，使用输出的第一行，如 pass:prod/db
+type passProvider struct{}
+
+func (passProvider) Get(name string) (string, error) {
+	cmd := exec.Command("pass", "show", name)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("执行 pass 失败: %v", err)
+	}
+
+	first, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimRight(first, "\r"), nil
+}
+
+// keyringProvider 从系统钥匙串读取，如 keyring:gossh/prod-db（服务名/账户名）
+// 省略服务名时默认使用 gossh
+type keyringProvider struct{}
+
+func (keyringProvider) Get(ref string) (string, error) {
+	service, user, ok := strings.Cut(ref, "/")
+	if !ok {
+		service, user = "gossh", ref
+	}
+
+	secret, err := keyring.Get(service, user)
+	if err != nil {
+		return "", fmt.Errorf("读取系统钥匙串失败: %v", err)
+	}
+	return secret, nil
+}
+
+// runCommand 通过系统 shell 执行命令并返回标准输出，标准输入不是终端时命令读不到输入
+func runCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	// 只在标准输入是终端时交给命令（用于输入口令等），否则可能读走要发送给远程命令的数据
+	if isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("执行命令失败: %v", err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,87 @@
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP 根据种子计算当前时间的一次性密码（RFC 6238）
+// seed 可以是 base32 编码的密钥，也可以是 otpauth://totp/... 形式的 URI（支持 digits、period、algorithm 参数）
+func TOTP(seed string, now time.Time) (string, error) {
+	secret := seed
+	digits := 6
+	period := 30
+	newHash := sha1.New
+
+	if strings.HasPrefix(seed, "otpauth://") {
+		u, err := url.Parse(seed)
+		if err != nil {
+			return "", fmt.Errorf("解析 TOTP URI 失败: %v", err)
+		}
+		if u.Host != "totp" {
+			return "", fmt.Errorf("不支持的一次性密码类型: %s（仅支持 totp）", u.Host)
+		}
+
+		query := u.Query()
+		secret = query.Get("secret")
+		if v := query.Get("digits"); v != "" {
+			if digits, err = strconv.Atoi(v); err != nil || digits < 6 || digits > 8 {
+				return "", fmt.Errorf("TOTP 位数无效: %s", v)
+			}
+		}
+		if v := query.Get("period"); v != "" {
+			if period, err = strconv.Atoi(v); err != nil || period <= 0 {
+				return "", fmt.Errorf("TOTP 周期无效: %s", v)
+			}
+		}
+		switch strings.ToUpper(query.Get("algorithm")) {
+		case "", "SHA1":
+		case "SHA256":
+			newHash = sha256.New
+		case "SHA512":
+			newHash = sha512.New
+		default:
+			return "", fmt.Errorf("不支持的 TOTP 算法: %s", query.Get("algorithm"))
+		}
+	}
+
+	// 兼容带空格、小写或缺少填充的密钥
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return "", fmt.Errorf("TOTP 种子不是有效的 base32 编码")
+	}
+
+	counter := uint64(now.Unix()) / uint64(period)
+	return hotp(newHash, key, counter, digits), nil
+}
+
+// hotp 计算基于计数器的一次性密码（RFC 4226）
+func hotp(newHash func() hash.Hash, key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(newHash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
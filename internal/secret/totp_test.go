@@ -0,0 +1,100 @@
+package secret
+
+import (
+	"encoding/base32"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 使用的种子（ASCII），SHA1、SHA256、SHA512 分别为 20、32、64 字节
+var (
+	rfcSeedSHA1   = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	rfcSeedSHA256 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
+	rfcSeedSHA512 = base32.StdEncoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234"))
+)
+
+func TestTOTPRFC6238(t *testing.T) {
+	tests := []struct {
+		unix   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		for _, c := range []struct {
+			algorithm, seed, want string
+		}{
+			{"SHA1", rfcSeedSHA1, tt.sha1},
+			{"SHA256", rfcSeedSHA256, tt.sha256},
+			{"SHA512", rfcSeedSHA512, tt.sha512},
+		} {
+			t.Run(fmt.Sprintf("%s/%d", c.algorithm, tt.unix), func(t *testing.T) {
+				uri := fmt.Sprintf("otpauth://totp/test?secret=%s&digits=8&algorithm=%s", c.seed, c.algorithm)
+				got, err := TOTP(uri, now)
+				if err != nil {
+					t.Fatalf("TOTP 返回错误: %v", err)
+				}
+				if got != c.want {
+					t.Errorf("TOTP = %s，应为 %s", got, c.want)
+				}
+			})
+		}
+
+		// 直接使用 base32 种子时为 SHA1、6 位，即 8 位结果的后 6 位
+		t.Run(fmt.Sprintf("默认参数/%d", tt.unix), func(t *testing.T) {
+			got, err := TOTP(rfcSeedSHA1, now)
+			if err != nil {
+				t.Fatalf("TOTP 返回错误: %v", err)
+			}
+			if want := tt.sha1[2:]; got != want {
+				t.Errorf("TOTP = %s，应为 %s", got, want)
+			}
+		})
+	}
+}
+
+func TestTOTPSeedFormats(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		name    string
+		seed    string
+		want    string
+		wantErr bool
+	}{
+		{"小写、空格、缺少填充", "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", "287082", false},
+		{"周期为 60 秒", "otpauth://totp/test?secret=" + rfcSeedSHA1 + "&period=60&digits=8", "84755224", false},
+		{"非 base32", "not-base32!", "", true},
+		{"空种子", "", "", true},
+		{"HOTP URI", "otpauth://hotp/test?secret=" + rfcSeedSHA1, "", true},
+		{"位数无效", "otpauth://totp/test?secret=" + rfcSeedSHA1 + "&digits=9", "", true},
+		{"算法不支持", "otpauth://totp/test?secret=" + rfcSeedSHA1 + "&algorithm=MD5", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TOTP(tt.seed, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("TOTP = %s，应返回错误", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TOTP 返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TOTP = %s，应为 %s", got, tt.want)
+			}
+		})
+	}
+}
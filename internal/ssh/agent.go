@@ -0,0 +1,62 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// agentConnection 表示与本地 ssh-agent 的连接
+type agentConnection struct {
+	conn   net.Conn
+	client agent.ExtendedAgent
+}
+
+// connectAgent 通过 SSH_AUTH_SOCK 连接本地 ssh-agent
+// 未设置 SSH_AUTH_SOCK 或连接失败时返回 nil，调用方应跳过 agent 认证
+func connectAgent() *agentConnection {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil
+	}
+
+	return &agentConnection{
+		conn:   conn,
+		client: agent.NewClient(conn),
+	}
+}
+
+// Signers 返回 agent 中的所有签名器
+func (a *agentConnection) Signers() ([]ssh.Signer, error) {
+	return a.client.Signers()
+}
+
+// Close 关闭与 agent 的连接
+func (a *agentConnection) Close() error {
+	return a.conn.Close()
+}
+
+// setupAgentForwarding 在SSH连接上注册 agent 转发通道处理器
+// 每个连接只需注册一次，之后各会话通过 requestAgentForwarding 开启转发
+func setupAgentForwarding(conn *ssh.Client, a *agentConnection) error {
+	if err := agent.ForwardToAgent(conn, a.client); err != nil {
+		return fmt.Errorf("设置 agent 转发失败: %v", err)
+	}
+	return nil
+}
+
+// requestAgentForwarding 为会话请求 agent 转发
+func requestAgentForwarding(session *ssh.Session) error {
+	if err := agent.RequestAgentForwarding(session); err != nil {
+		return fmt.Errorf("请求 agent 转发失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package ssh
+
+import (
+	"slices"
+
+	"goSSH/internal/config"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// algorithmConfig 根据服务器配置返回加密、密钥交换、MAC 算法设置和主机密钥算法列表
+// 未配置的类别为 nil，使用 x/crypto 的默认值
+func algorithmConfig(server *models.Server) (ssh.Config, []string, error) {
+	return config.ResolveAlgorithms(server)
+}
+
+// preferKnownHostKeys 将已记录在 known_hosts 中的密钥算法排在配置的主机密钥算法前面
+// 未配置主机密钥算法时直接使用已记录的算法（为空时使用默认值）
+func preferKnownHostKeys(configured, known []string) []string {
+	if len(configured) == 0 {
+		return known
+	}
+
+	result := make([]string, 0, len(configured))
+	for _, algo := range configured {
+		if slices.Contains(known, algo) {
+			result = append(result, algo)
+		}
+	}
+	for _, algo := range configured {
+		if !slices.Contains(known, algo) {
+			result = append(result, algo)
+		}
+	}
+	return result
+}
+
+// IsInsecureAlgorithm 判断算法是否为 x/crypto 认为存在安全问题的算法
+func IsInsecureAlgorithm(name string) bool {
+	insecure := ssh.InsecureAlgorithms()
+	return slices.Contains(insecure.Ciphers, name) ||
+		slices.Contains(insecure.KeyExchanges, name) ||
+		slices.Contains(insecure.MACs, name) ||
+		slices.Contains(insecure.HostKeys, name)
+}
+
+// Algorithms 返回与服务器协商的算法，未连接时返回 false
+// 通过主连接进程连接时返回的是与主连接进程之间的算法
+func (c *Client) Algorithms() (ssh.NegotiatedAlgorithms, bool) {
+	conn := c.GetConnection()
+	if conn == nil {
+		return ssh.NegotiatedAlgorithms{}, false
+	}
+	meta, ok := conn.Conn.(ssh.AlgorithmsConnMetadata)
+	if !ok {
+		return ssh.NegotiatedAlgorithms{}, false
+	}
+	return meta.Algorithms(), true
+}
@@ -0,0 +1,162 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"goSSH/internal/secret"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// AuthError 表示服务器拒绝了所有可用的认证方式
+type AuthError struct {
+	Address string // 服务器地址 host:port
+	Err     error  // SSH 握手返回的原始错误
+}
+
+func (e *AuthError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// isAuthFailure 判断 SSH 握手错误是否由认证失败引起（SSH 库没有为此提供专门的错误类型）
+func isAuthFailure(err error) bool {
+	return strings.Contains(err.Error(), "ssh: unable to authenticate")
+}
+
+// buildAuthMethods 根据服务器配置构建认证方式列表
+// 顺序为：公钥认证（用户证书 + 私钥文件 + ssh-agent） -> 密码认证 -> keyboard-interactive，SSH库会按顺序依次尝试
+// 注意：同一种认证方式只会被尝试一次，因此私钥文件和 agent 的签名器必须合并到同一个公钥认证中
+func buildAuthMethods(server *models.Server, agentConn *agentConnection) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	var signers []ssh.Signer
+
+	// 配置了密码引用时，在连接前从对应的来源获取密码
+	password := server.Password
+	if server.PasswordRef != "" {
+		resolved, err := secret.Resolve(server.PasswordRef)
+		if err != nil {
+			return nil, err
+		}
+		password = resolved
+	}
+
+	if server.IdentityFile != "" {
+		signer, err := loadSigner(server.IdentityFile, server.Passphrase)
+		if err != nil {
+			// 没有其他认证方式可回退时直接报错，否则提示后继续
+			if password == "" && agentConn == nil {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "警告: %v，将尝试其他认证方式\n", err)
+		} else {
+			// 存在用户证书时优先使用证书认证，同时保留私钥本身作为回退
+			certSigner, err := certSigner(server, signer)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "警告: %v，将使用私钥本身认证\n", err)
+			} else if certSigner != nil {
+				signers = append(signers, certSigner)
+			}
+			signers = append(signers, signer)
+		}
+	}
+
+	if len(signers) > 0 || agentConn != nil {
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			all := append([]ssh.Signer{}, signers...)
+			if agentConn != nil {
+				// agent 出错时不影响其他认证方式
+				if agentSigners, err := agentConn.Signers(); err == nil {
+					all = append(all, agentSigners...)
+				}
+			}
+			return all, nil
+		}))
+	}
+
+	// 未配置其他认证方式时始终保留密码认证，保持原有行为
+	if password != "" || len(methods) == 0 {
+		methods = append(methods, ssh.Password(password))
+	}
+
+	// 服务器要求 keyboard-interactive（如密码 + 一次性密码）时，自动回答已知的问题，其余问题询问用户
+	methods = append(methods, ssh.KeyboardInteractive(keyboardInteractive(server, password)))
+
+	return methods, nil
+}
+
+// loadSigner 读取私钥文件并解析为签名器
+// 如果私钥已加密且未提供口令，则交互式询问口令
+func loadSigner(path, passphrase string) (ssh.Signer, error) {
+	keyPath, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取私钥文件失败: %v", err)
+	}
+
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %v", err)
+		}
+		return signer, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if err == nil {
+		return signer, nil
+	}
+
+	var missingErr *ssh.PassphraseMissingError
+	if !errors.As(err, &missingErr) {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+
+	// 私钥已加密，询问口令；标准输入不是终端时（如通过管道向远程命令发送数据）不能读取，否则会读走要发送的数据
+	if !canPrompt() {
+		return nil, fmt.Errorf("私钥 %s 已加密，但%w，无法询问口令；可以配置 passphrase 或使用 ssh-agent", keyPath, errNoTerminal)
+	}
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	prompt := promptui.Prompt{
+		Label:  fmt.Sprintf("私钥 %s 的口令", filepath.Base(keyPath)),
+		Mask:   '*',
+		Stdout: os.Stderr,
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("输入私钥口令取消: %v", err)
+	}
+
+	signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(input))
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+	return signer, nil
+}
+
+// ExpandPath 展开路径中的 ~ 为用户主目录
+func ExpandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %v", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,166 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// hostCertAlgorithms 配置了主机 CA 时优先请求的主机证书算法
+var hostCertAlgorithms = []string{
+	ssh.CertAlgoED25519v01,
+	ssh.CertAlgoECDSA256v01,
+	ssh.CertAlgoECDSA384v01,
+	ssh.CertAlgoECDSA521v01,
+	ssh.CertAlgoRSASHA512v01,
+	ssh.CertAlgoRSASHA256v01,
+}
+
+// CertificatePath 返回服务器使用的用户证书路径
+// 未配置 certificate_file 时，如果私钥同目录下存在 <私钥>-cert.pub 则自动使用；没有证书时返回空字符串
+func CertificatePath(server *models.Server) (string, error) {
+	if server.CertificateFile != "" {
+		return ExpandPath(server.CertificateFile)
+	}
+	if server.IdentityFile == "" {
+		return "", nil
+	}
+
+	keyPath, err := ExpandPath(server.IdentityFile)
+	if err != nil {
+		return "", err
+	}
+	certPath := keyPath + "-cert.pub"
+	if _, err := os.Stat(certPath); err != nil {
+		return "", nil
+	}
+	return certPath, nil
+}
+
+// LoadCertificate 读取服务器配置的用户证书，没有证书时返回 nil
+func LoadCertificate(server *models.Server) (*ssh.Certificate, string, error) {
+	path, err := CertificatePath(server)
+	if err != nil || path == "" {
+		return nil, path, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("读取证书文件失败: %v", err)
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, path, fmt.Errorf("解析证书 %s 失败: %v", path, err)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok || cert.CertType != ssh.UserCert {
+		return nil, path, fmt.Errorf("%s 不是用户证书", path)
+	}
+	return cert, path, nil
+}
+
+// CertificateExpiry 返回证书的过期时间，永久有效时返回 false
+func CertificateExpiry(cert *ssh.Certificate) (time.Time, bool) {
+	if cert.ValidBefore == ssh.CertTimeInfinity || cert.ValidBefore > 1<<63-1 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(cert.ValidBefore), 0), true
+}
+
+// CertificateExpiresSoon 判断证书是否即将过期
+// 剩余时间少于 24 小时或少于有效期的 20% 时（取较短者）视为即将过期，以适应短期证书
+func CertificateExpiresSoon(cert *ssh.Certificate, now time.Time) bool {
+	expiry, ok := CertificateExpiry(cert)
+	if !ok {
+		return false
+	}
+
+	threshold := 24 * time.Hour
+	validity := expiry.Sub(time.Unix(int64(cert.ValidAfter), 0))
+	if validity/5 < threshold {
+		threshold = validity / 5
+	}
+	return expiry.Sub(now) < threshold
+}
+
+// certSigner 使用服务器配置的用户证书包装私钥签名器
+// 没有证书时返回 nil；证书不可用（已过期、与私钥不匹配等）时返回错误，由调用方决定是否回退到普通公钥认证
+func certSigner(server *models.Server, signer ssh.Signer) (ssh.Signer, error) {
+	cert, path, err := LoadCertificate(server)
+	if err != nil || cert == nil {
+		return nil, err
+	}
+
+	if expiry, ok := CertificateExpiry(cert); ok && time.Now().After(expiry) {
+		return nil, fmt.Errorf("证书 %s 已于 %s 过期", path, expiry.Format("2006-01-02 15:04"))
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("证书 %s 与私钥不匹配: %v", path, err)
+	}
+	return certSigner, nil
+}
+
+// loadHostCAKeys 读取受信任的主机 CA 公钥（authorized_keys 格式，每行一个）
+func loadHostCAKeys(path string) ([]ssh.PublicKey, error) {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("读取主机 CA 文件失败: %v", err)
+	}
+
+	var keys []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("解析主机 CA 文件 %s 失败: %v", path, err)
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("主机 CA 文件 %s 中没有公钥", path)
+	}
+	return keys, nil
+}
+
+// hostCertCallback 返回先校验主机证书、非证书密钥交给 fallback 处理的主机密钥回调
+// 由受信任的 CA 签发且主机名在证书允许范围内的主机证书无需记录在 known_hosts 中
+func hostCertCallback(caKeys []ssh.PublicKey, fallback ssh.HostKeyCallback) ssh.HostKeyCallback {
+	checker := &ssh.CertChecker{
+		IsHostAuthority: func(auth ssh.PublicKey, address string) bool {
+			for _, ca := range caKeys {
+				if bytes.Equal(ca.Marshal(), auth.Marshal()) {
+					return true
+				}
+			}
+			return false
+		},
+		HostKeyFallback: fallback,
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := checker.CheckHostKey(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var hostErr *HostKeyError
+		if _, isCert := key.(*ssh.Certificate); isCert && !errors.As(err, &hostErr) {
+			return &HostKeyError{Address: hostname, Key: key, Rejected: true, Description: "主机证书校验失败: " + err.Error()}
+		}
+		return err
+	}
+}
@@ -1,7 +1,14 @@
 package ssh
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -9,9 +16,28 @@ import (
 )
 
 // Client SSH客户端封装
+// 连接相关的字段由 mu 保护，可以在一个 goroutine 中重连的同时在另一个 goroutine 中关闭
 type Client struct {
 	server *models.Server
+
+	noMaster bool // 不通过主连接进程建立连接
+
+	mu     sync.Mutex
 	conn   *ssh.Client
+	jumps  []*ssh.Client    // 跳板机连接（按连接顺序）
+	agent  *agentConnection // 本地 ssh-agent 连接（可能为 nil）
+	state  *connState       // 当前连接的状态
+	closed bool             // 已调用 Close，不再建立新的连接
+}
+
+// errClientClosed 表示客户端已关闭，连接期间关闭时新建立的连接会被丢弃
+var errClientClosed = errors.New("客户端已关闭")
+
+// connState 表示一次连接的状态，重连后会替换为新的状态
+type connState struct {
+	done    chan struct{} // 连接断开时关闭
+	err     error         // 连接断开的原因
+	closing atomic.Bool   // 是否为主动关闭
 }
 
 // NewClient 创建新的SSH客户端
@@ -21,43 +47,276 @@ func NewClient(server *models.Server) *Client {
 	}
 }
 
+// newClientConfig 根据服务器配置构建SSH客户端配置
+func newClientConfig(server *models.Server, agentConn *agentConnection, timeout time.Duration) (*ssh.ClientConfig, error) {
+	auth, err := buildAuthMethods(server, agentConn)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithms, configuredHostKeys, err := algorithmConfig(server)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback, knownHostKeys, err := hostKeyConfig(server, ServerAddress(server))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		Config:            algorithms,
+		User:              server.Username,
+		Auth:              auth,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: preferKnownHostKeys(configuredHostKeys, knownHostKeys),
+		Timeout:           timeout,
+	}, nil
+}
+
+// ServerAddress 返回服务器的 host:port 地址
+func ServerAddress(server *models.Server) string {
+	return net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+}
+
 // Connect 建立SSH连接
+// 配置了跳板机时，会依次连接各跳板机，再通过最后一跳连接目标服务器
+// 启用 control_master 时优先复用主连接进程中的连接；主连接进程不可用，或连接时需要询问用户（如确认主机密钥）时直接连接，
+// 主连接进程连接服务器的其他错误（如认证失败）直接返回，不再重复连接
 func (c *Client) Connect() error {
-	config := &ssh.ClientConfig{
-		User: c.server.Username,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(c.server.Password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 忽略主机密钥验证（适合内网环境）
-		Timeout:         10 * time.Second,
+	return c.ConnectContext(context.Background())
+}
+
+// ConnectContext 与 Connect 相同，ctx 结束时放弃正在建立的连接并返回 ctx 的错误
+func (c *Client) ConnectContext(ctx context.Context) error {
+	if c.useMaster() {
+		err := c.connectMaster(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var unavailable *masterUnavailableError
+		if !errors.As(err, &unavailable) {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := c.connect(ctx, 10*time.Second); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("连接服务器失败: %w", err)
 	}
+	return nil
+}
 
-	address := fmt.Sprintf("%s:%d", c.server.Host, c.server.Port)
-	conn, err := ssh.Dial("tcp", address, config)
+// connect 建立到目标服务器的连接（包括跳板机链）
+func (c *Client) connect(ctx context.Context, timeout time.Duration) error {
+	agentConn, err := c.localAgent()
 	if err != nil {
-		return fmt.Errorf("连接服务器失败: %v", err)
+		return err
 	}
 
-	c.conn = conn
+	chain, err := resolveJumpChain(c.server)
+	if err != nil {
+		return err
+	}
+
+	jumps, err := connectJumps(ctx, chain, agentConn, timeout)
+	if err != nil {
+		return err
+	}
+
+	var prev *ssh.Client
+	if len(jumps) > 0 {
+		prev = jumps[len(jumps)-1]
+	}
+
+	conn, err := dialServer(ctx, prev, c.server, agentConn, timeout)
+	if err != nil {
+		closeClients(jumps)
+		return err
+	}
+
+	if c.server.ForwardAgent {
+		if agentConn == nil {
+			fmt.Fprintln(os.Stderr, "警告: 未找到可用的 ssh-agent（SSH_AUTH_SOCK），无法转发 agent")
+		} else if err := setupAgentForwarding(conn, agentConn); err != nil {
+			conn.Close()
+			closeClients(jumps)
+			return err
+		}
+	}
+
+	state, err := c.setConn(conn, jumps)
+	if err != nil {
+		return err
+	}
+
+	go c.monitor(conn, state, true)
 	return nil
 }
 
-// Close 关闭SSH连接
+// localAgent 返回本地 ssh-agent 连接（可能为 nil），首次调用时建立
+func (c *Client) localAgent() (*agentConnection, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errClientClosed
+	}
+	if c.agent == nil {
+		c.agent = connectAgent()
+	}
+	return c.agent, nil
+}
+
+// setConn 保存新建立的连接并返回其状态
+// 连接期间客户端已被关闭时关闭该连接并返回 errClientClosed，避免连接泄漏
+func (c *Client) setConn(conn *ssh.Client, jumps []*ssh.Client) (*connState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		conn.Close()
+		closeClients(jumps)
+		return nil, errClientClosed
+	}
+
+	state := &connState{done: make(chan struct{})}
+	c.conn = conn
+	c.jumps = jumps
+	c.state = state
+	return state, nil
+}
+
+// monitor 运行保活（withKeepalive 为 true 时）并等待连接断开，断开后记录原因并通知等待者
+func (c *Client) monitor(conn *ssh.Client, state *connState, withKeepalive bool) {
+	stop := make(chan struct{})
+	var timedOut atomic.Bool
+	if interval, countMax := keepaliveConfig(c.server); withKeepalive && interval > 0 {
+		go keepalive(conn, interval, countMax, stop, &timedOut)
+	}
+
+	err := conn.Wait()
+	close(stop)
+
+	switch {
+	case state.closing.Load():
+		err = nil
+	case timedOut.Load():
+		err = ErrKeepaliveTimeout
+	case err == nil:
+		err = fmt.Errorf("服务器关闭了连接")
+	}
+
+	c.mu.Lock()
+	state.err = err
+	c.mu.Unlock()
+
+	close(state.done)
+}
+
+// Done 返回一个在当前连接断开时关闭的通道，尚未连接时返回 nil
+// 调用方可以在通道关闭后通过 Err 获取原因，并调用 Reconnect 或 ReconnectWithBackoff 恢复连接
+func (c *Client) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		return nil
+	}
+	return c.state.done
+}
+
+// Err 返回连接断开的原因，连接正常或为主动关闭时返回 nil
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		return nil
+	}
+	return c.state.err
+}
+
+// waitDisconnect 在 timeout 内等待连接断开，返回断开的原因；连接仍正常时返回 nil
+// 用于区分会话自身的错误和连接断开导致的错误
+func (c *Client) waitDisconnect(timeout time.Duration) error {
+	done := c.Done()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return c.Err()
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// closeConn 关闭到目标服务器及各跳板机的连接
+func (c *Client) closeConn() error {
+	c.mu.Lock()
+	if c.state != nil {
+		c.state.closing.Store(true)
+	}
+	conn, jumps := c.conn, c.jumps
+	c.conn, c.jumps = nil, nil
+	c.mu.Unlock()
+
+	var err error
+	if conn != nil {
+		err = conn.Close()
+	}
+	closeClients(jumps)
+	return err
+}
+
+// Close 关闭SSH连接，关闭后客户端不能再次连接；正在建立的连接完成后会被关闭
 func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+	c.mu.Lock()
+	c.closed = true
+	agentConn := c.agent
+	c.agent = nil
+	c.mu.Unlock()
+
+	if agentConn != nil {
+		agentConn.Close()
 	}
-	return nil
+	return c.closeConn()
+}
+
+// DisableMaster 禁止通过主连接进程建立连接
+// 用于端口转发等需要独占连接、或需要服务器向本进程打开通道的场景
+func (c *Client) DisableMaster() {
+	c.noMaster = true
+}
+
+// prepareSession 根据服务器配置为会话开启 agent 转发
+func (c *Client) prepareSession(session *ssh.Session) error {
+	c.mu.Lock()
+	agentConn := c.agent
+	c.mu.Unlock()
+
+	if !c.server.ForwardAgent || agentConn == nil {
+		return nil
+	}
+	return requestAgentForwarding(session)
 }
 
 // GetConnection 获取SSH连接（用于执行命令或文件传输）
 func (c *Client) GetConnection() *ssh.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.conn
 }
 
 // IsConnected 检查是否已连接
 func (c *Client) IsConnected() bool {
-	return c.conn != nil
+	return c.GetConnection() != nil
 }
 
 // GetServer 获取服务器配置
@@ -65,32 +324,19 @@ func (c *Client) GetServer() *models.Server {
 	return c.server
 }
 
-// Reconnect 重连SSH服务器
-func (c *Client) Reconnect() error {
-	if c.conn != nil {
-		c.conn.Close()
-		c.conn = nil
-	}
-	return c.Connect()
+// Reconnect 关闭当前连接并重新连接SSH服务器，ctx 结束时放弃连接
+func (c *Client) Reconnect(ctx context.Context) error {
+	c.closeConn()
+	return c.ConnectContext(ctx)
 }
 
 // TestConnection 测试连接（不保持连接）
 func TestConnection(server *models.Server) error {
-	config := &ssh.ClientConfig{
-		User: server.Username,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(server.Password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         5 * time.Second,
-	}
+	client := NewClient(server)
+	defer client.Close()
 
-	address := fmt.Sprintf("%s:%d", server.Host, server.Port)
-	conn, err := ssh.Dial("tcp", address, config)
-	if err != nil {
-		return fmt.Errorf("连接测试失败: %v", err)
+	if err := client.connect(context.Background(), 5*time.Second); err != nil {
+		return fmt.Errorf("连接测试失败: %w", err)
 	}
-	defer conn.Close()
-
 	return nil
 }
@@ -1,37 +1,47 @@
-// +build windows
-
 package ssh
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/sys/windows"
 )
 
-// crlfFilterReader 过滤掉Windows终端发送的\r字符，只保留\n
-// 这样可以避免在SSH会话中出现双重回车的问题
-type crlfFilterReader struct {
-	reader io.Reader
-}
+// 远程命令执行失败时使用的保留退出码，与 OpenSSH 一样，其他连接错误使用 255
+const (
+	ExitTimeout          = 124 // 命令执行超时（与 timeout 命令一致）
+	ExitInterrupted      = 130 // 被 Ctrl+C 中断，未执行或被强制断开（128+SIGINT）
+	ExitHostKeyFailed    = 253 // 主机密钥校验失败
+	ExitAuthFailed       = 254 // 认证失败
+	ExitConnectionFailed = 255 // 无法连接服务器、连接中断等其他错误
+)
 
-func (r *crlfFilterReader) Read(p []byte) (n int, err error) {
-	n, err = r.reader.Read(p)
-	if n > 0 {
-		// 过滤掉\r字符
-		writeIdx := 0
-		for i := 0; i < n; i++ {
-			if p[i] != '\r' {
-				p[writeIdx] = p[i]
-				writeIdx++
-			}
-		}
-		n = writeIdx
+// ExitCode 返回执行远程命令的错误对应的进程退出码
+// 成功时为 0，命令以非零状态退出时为远程命令的退出码（被信号终止时为 128+信号值），其他错误使用保留退出码
+func ExitCode(err error) int {
+	var exitErr *ssh.ExitError
+	var hostKeyErr *HostKeyError
+	var authErr *AuthError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitStatus()
+	case errors.Is(err, ErrTimeout):
+		return ExitTimeout
+	case errors.Is(err, ErrInterrupted):
+		return ExitInterrupted
+	case errors.As(err, &hostKeyErr):
+		return ExitHostKeyFailed
+	case errors.As(err, &authErr):
+		return ExitAuthFailed
+	default:
+		return ExitConnectionFailed
 	}
-	return n, err
 }
 
 // Executor 提供远程命令执行功能
@@ -81,10 +91,10 @@ func (e *Executor) ExecuteInteractive(command string) error {
 	defer session.Close()
 
 	// 设置标准输入输出
-	// 在Windows上，使用crlfFilterReader过滤掉\r字符，避免双重回车问题
+	// 在Windows上，stdinReader 会过滤掉\r字符，避免双重回车问题
 	session.Stdout = os.Stdout
 	session.Stderr = os.Stderr
-	session.Stdin = &crlfFilterReader{reader: os.Stdin}
+	session.Stdin = stdinReader()
 
 	// 设置伪终端（PTY）用于交互式命令
 	// ECHO 设置为 0，禁用远程回显，由本地终端负责回显，避免命令重复显示
@@ -111,11 +121,55 @@ func (e *Executor) ExecuteInteractive(command string) error {
 
 // ExecuteWithStream 执行命令并实时流式输出
 func (e *Executor) ExecuteWithStream(command string) error {
+	err := e.ExecuteWithWriters(command, os.Stdout, os.Stderr)
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("命令执行失败，退出码: %d", exitErr.ExitStatus())
+	}
+	return err
+}
+
+// ExecuteWithWriters 执行命令，将标准输出和错误输出实时写入 stdout 和 stderr
+// 命令以非零状态退出或被信号终止时返回 *ssh.ExitError，调用方可以从中获取退出码
+func (e *Executor) ExecuteWithWriters(command string, stdout, stderr io.Writer) error {
+	return e.ExecuteContext(context.Background(), command, ExecOptions{Stdout: stdout, Stderr: stderr})
+}
+
+// ExecOptions 是执行远程命令的选项
+type ExecOptions struct {
+	Stdin  io.Reader    // 发送给远程命令的标准输入，读到 EOF 时关闭远程命令的标准输入；为 nil 时远程命令读不到输入。远程命令结束后不再等待读取
+	Stdout io.Writer    // 标准输出的写入位置，为 nil 时丢弃
+	Stderr io.Writer    // 错误输出的写入位置，为 nil 时丢弃
+	Relay  *SignalRelay // 不为 nil 时，本地收到的信号会转发给远程命令
+}
+
+// timeoutGrace 超时后发送 TERM 信号到强制关闭会话之间的等待时间
+const timeoutGrace = 5 * time.Second
+
+// ExecuteContext 执行命令，连接期间 ctx 结束时放弃连接；命令执行期间 ctx 结束（如超时）时向远程命令发送 TERM 信号，
+// 等待 timeoutGrace 后仍未结束则关闭会话，超时时返回 ErrTimeout
+// 命令以非零状态退出或被信号终止时返回 *ssh.ExitError
+func (e *Executor) ExecuteContext(ctx context.Context, command string, opts ExecOptions) error {
+	// 连接期间第二次按 Ctrl+C 时放弃连接
+	if opts.Relay != nil {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		unwatch := opts.Relay.watch(cancel)
+		defer unwatch()
+	}
+
 	if !e.client.IsConnected() {
-		if err := e.client.Connect(); err != nil {
+		if err := e.client.ConnectContext(ctx); err != nil {
+			if ctx.Err() != nil {
+				return contextError(ctx)
+			}
 			return err
 		}
 	}
+	if ctx.Err() != nil {
+		return contextError(ctx)
+	}
 
 	session, err := e.client.GetConnection().NewSession()
 	if err != nil {
@@ -123,56 +177,87 @@ func (e *Executor) ExecuteWithStream(command string) error {
 	}
 	defer session.Close()
 
-	// 获取标准输出和错误输出
-	stdout, err := session.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("获取标准输出管道失败: %v", err)
+	if err := e.client.prepareSession(session); err != nil {
+		return err
 	}
 
-	stderr, err := session.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("获取错误输出管道失败: %v", err)
+	// 标准输入由自己的 goroutine 复制，session.Wait 不等待它：
+	// 输入一直不结束（如 cron 或 CI 中未关闭的管道）时，远程命令退出后也能立即返回
+	var stdin io.WriteCloser
+	if opts.Stdin != nil {
+		if stdin, err = session.StdinPipe(); err != nil {
+			return fmt.Errorf("创建会话失败: %v", err)
+		}
+	}
+	session.Stdout = opts.Stdout
+	session.Stderr = opts.Stderr
+
+	// 已收到中断信号时不再启动命令
+	if opts.Relay != nil {
+		if !opts.Relay.add(session) {
+			return ErrInterrupted
+		}
+		defer opts.Relay.remove(session)
 	}
 
 	// 启动命令
 	if err := session.Start(command); err != nil {
 		return fmt.Errorf("启动命令失败: %v", err)
 	}
+	if stdin != nil {
+		go func() {
+			io.Copy(stdin, opts.Stdin)
+			stdin.Close()
+		}()
+	}
 
-	// 实时输出
-	done := make(chan bool, 2)
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			fmt.Println(scanner.Text())
+	// 等待命令执行完成（输出全部写入后才返回）
+	waitCh := make(chan error, 1)
+	go func() { waitCh <- session.Wait() }()
+
+	select {
+	case err = <-waitCh:
+	case <-ctx.Done():
+		session.Signal(ssh.SIGTERM)
+		select {
+		case <-waitCh:
+		case <-time.After(timeoutGrace):
+			session.Close()
 		}
-		done <- true
-	}()
+		return contextError(ctx)
+	}
 
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			fmt.Fprintln(os.Stderr, scanner.Text())
+	if err != nil {
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr
 		}
-		done <- true
-	}()
-
-	// 等待输出完成
-	<-done
-	<-done
-
-	// 等待命令执行完成
-	if err := session.Wait(); err != nil {
-		if exitErr, ok := err.(*ssh.ExitError); ok {
-			return fmt.Errorf("命令执行失败，退出码: %d", exitErr.ExitStatus())
+		if opts.Relay != nil && opts.Relay.remove(session) {
+			return ErrInterrupted
+		}
+		if connErr := e.client.waitDisconnect(time.Second); connErr != nil {
+			return fmt.Errorf("与服务器的连接已断开: %v", connErr)
 		}
 		return fmt.Errorf("等待命令完成失败: %v", err)
 	}
-
 	return nil
 }
 
+// ErrTimeout 表示命令超过了 context 的截止时间，已被终止
+var ErrTimeout = errors.New("命令执行超时，已终止")
+
+// contextError 返回 ctx 结束时的错误
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	switch {
+	case errors.Is(context.Cause(ctx), ErrInterrupted):
+		return ErrInterrupted
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	}
+	return fmt.Errorf("命令已取消: %w", err)
+}
+
 // ExecuteShell 启动交互式Shell
 // 如果 useNewTab 为 false，则优先尝试在新标签页中启动，失败后尝试新窗口，最后回退到当前终端
 func (e *Executor) ExecuteShell(useNewTab bool) error {
@@ -228,7 +313,7 @@ func (e *Executor) ExecuteShellInNewWindow() error {
 	return OpenInNewWindow(execPath, cmdArgs...)
 }
 
-// executeShellInCurrentTerminal 在当前终端中启动交互式Shell（原有逻辑）
+// executeShellInCurrentTerminal 在当前终端中启动交互式Shell
 func (e *Executor) executeShellInCurrentTerminal() error {
 	if !e.client.IsConnected() {
 		if err := e.client.Connect(); err != nil {
@@ -242,11 +327,15 @@ func (e *Executor) executeShellInCurrentTerminal() error {
 	}
 	defer session.Close()
 
+	if err := e.client.prepareSession(session); err != nil {
+		return err
+	}
+
 	// 设置标准输入输出
-	// 在Windows上，使用crlfFilterReader过滤掉\r字符，避免双重回车问题
+	// 在Windows上，stdinReader 会过滤掉\r字符，避免双重回车问题
 	session.Stdout = os.Stdout
 	session.Stderr = os.Stderr
-	session.Stdin = &crlfFilterReader{reader: os.Stdin}
+	session.Stdin = stdinReader()
 
 	// 设置伪终端
 	// ECHO 设置为 0，禁用远程回显，由本地终端负责回显，避免命令重复显示
@@ -275,7 +364,15 @@ func (e *Executor) executeShellInCurrentTerminal() error {
 	}
 
 	// 等待会话结束
-	return session.Wait()
+	if err := session.Wait(); err != nil {
+		if _, ok := err.(*ssh.ExitError); !ok {
+			if connErr := e.client.waitDisconnect(time.Second); connErr != nil {
+				return fmt.Errorf("与服务器的连接已断开: %v", connErr)
+			}
+		}
+		return err
+	}
+	return nil
 }
 
 // CopyOutput 复制输出到指定的writer
@@ -303,13 +400,3 @@ func (e *Executor) CopyOutput(command string, writer io.Writer) error {
 	_, err = writer.Write(output)
 	return err
 }
-
-// getTerminalSize 获取终端大小（Windows系统）
-func getTerminalSize(fd int) (width, height int) {
-	var info windows.ConsoleScreenBufferInfo
-	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err == nil {
-		width = int(info.Window.Right - info.Window.Left + 1)
-		height = int(info.Window.Bottom - info.Window.Top + 1)
-	}
-	return width, height
-}
@@ -0,0 +1,49 @@
+//go:build windows
+// +build windows
+
+package ssh
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// crlfFilterReader 过滤掉Windows终端发送的\r字符，只保留\n
+// 这样可以避免在SSH会话中出现双重回车的问题
+type crlfFilterReader struct {
+	reader io.Reader
+}
+
+func (r *crlfFilterReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	if n > 0 {
+		// 过滤掉\r字符
+		writeIdx := 0
+		for i := 0; i < n; i++ {
+			if p[i] != '\r' {
+				p[writeIdx] = p[i]
+				writeIdx++
+			}
+		}
+		n = writeIdx
+	}
+	return n, err
+}
+
+// stdinReader 返回用于远程会话的标准输入
+// Windows终端会发送\r\n，使用crlfFilterReader过滤掉\r字符
+func stdinReader() io.Reader {
+	return &crlfFilterReader{reader: os.Stdin}
+}
+
+// getTerminalSize 获取终端大小（Windows系统）
+func getTerminalSize(fd int) (width, height int) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err == nil {
+		width = int(info.Window.Right - info.Window.Left + 1)
+		height = int(info.Window.Bottom - info.Window.Top + 1)
+	}
+	return width, height
+}
@@ -0,0 +1,365 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ForwardSpec 表示一条端口转发规则，格式为 [bind_address:]port:host:hostport
+type ForwardSpec struct {
+	BindAddress string // 监听地址
+	BindPort    int    // 监听端口
+	Host        string // 目标主机
+	Port        int    // 目标端口
+}
+
+// ParseForwardSpec 解析端口转发规则，IPv6 地址需要使用方括号，如 [::1]:8080:db:5432
+// 未指定监听地址时使用 defaultBind
+func ParseForwardSpec(spec, defaultBind string) (ForwardSpec, error) {
+	parts, err := splitForwardSpec(spec)
+	if err != nil {
+		return ForwardSpec{}, err
+	}
+
+	var result ForwardSpec
+	switch len(parts) {
+	case 3:
+		result.BindAddress = defaultBind
+	case 4:
+		result.BindAddress = parts[0]
+		parts = parts[1:]
+	default:
+		return ForwardSpec{}, fmt.Errorf("转发规则 '%s' 格式错误，应为 [bind_address:]port:host:hostport", spec)
+	}
+
+	if result.BindPort, err = parsePort(parts[0], true); err != nil {
+		return ForwardSpec{}, fmt.Errorf("转发规则 '%s' 的监听端口错误: %v", spec, err)
+	}
+	if result.Port, err = parsePort(parts[2], false); err != nil {
+		return ForwardSpec{}, fmt.Errorf("转发规则 '%s' 的目标端口错误: %v", spec, err)
+	}
+	result.Host = parts[1]
+	if result.Host == "" {
+		return ForwardSpec{}, fmt.Errorf("转发规则 '%s' 缺少目标主机", spec)
+	}
+
+	return result, nil
+}
+
+// splitForwardSpec 按冒号拆分转发规则，方括号内的冒号不拆分
+func splitForwardSpec(spec string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	inBracket := false
+
+	for _, r := range spec {
+		switch {
+		case r == '[' && !inBracket:
+			inBracket = true
+		case r == ']' && inBracket:
+			inBracket = false
+		case r == ':' && !inBracket:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if inBracket {
+		return nil, fmt.Errorf("转发规则 '%s' 中的方括号不匹配", spec)
+	}
+	return append(parts, current.String()), nil
+}
+
+// parsePort 解析端口号，allowZero 表示是否允许 0（由系统分配端口）
+func parsePort(value string, allowZero bool) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("端口必须是数字")
+	}
+	if port < 0 || port > 65535 || (port == 0 && !allowZero) {
+		return 0, fmt.Errorf("端口范围必须在1-65535之间")
+	}
+	return port, nil
+}
+
+// ListenAddress 返回监听地址
+func (s ForwardSpec) ListenAddress() string {
+	return net.JoinHostPort(s.BindAddress, strconv.Itoa(s.BindPort))
+}
+
+// TargetAddress 返回转发的目标地址
+func (s ForwardSpec) TargetAddress() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// String 返回转发规则的字符串表示
+func (s ForwardSpec) String() string {
+	if s.Host == "" {
+		return s.ListenAddress() + " (SOCKS5)"
+	}
+	return s.ListenAddress() + " -> " + s.TargetAddress()
+}
+
+// ForwardStats 端口转发的流量统计，可以在多个转发器之间共享（如重连后继续累计）
+type ForwardStats struct {
+	Connections   atomic.Int64 // 累计连接数
+	Active        atomic.Int64 // 当前活动连接数
+	BytesSent     atomic.Int64 // 发往目标地址的字节数
+	BytesReceived atomic.Int64 // 从目标地址接收的字节数
+}
+
+// Forwarder 端口转发器，接受监听端口上的连接，并将其与目标地址的连接双向转发
+type Forwarder struct {
+	Spec ForwardSpec
+
+	// Stats 流量统计，在调用 Serve 之前可以替换为共享的统计
+	Stats *ForwardStats
+
+	// Logf 用于输出每个连接的日志（可选）
+	Logf func(format string, args ...any)
+
+	listener net.Listener
+	connect  connectFunc
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// ForwardLocal 创建本地端口转发：在本地监听，通过SSH连接访问目标地址
+func ForwardLocal(client *Client, spec ForwardSpec) (*Forwarder, error) {
+	if !client.IsConnected() {
+		if err := client.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", spec.ListenAddress())
+	if err != nil {
+		return nil, fmt.Errorf("监听本地地址 %s 失败: %v", spec.ListenAddress(), err)
+	}
+
+	conn := client.GetConnection()
+	return newForwarder(spec, listener, dialTarget(spec.TargetAddress(), func(address string) (net.Conn, error) {
+		return conn.Dial("tcp", address)
+	})), nil
+}
+
+// ForwardRemote 创建远程端口转发：请求服务器在远程地址上监听，并将连接转发到本地可访问的目标地址
+func ForwardRemote(client *Client, spec ForwardSpec) (*Forwarder, error) {
+	if !client.IsConnected() {
+		if err := client.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := client.GetConnection().Listen("tcp", spec.ListenAddress())
+	if err != nil {
+		if strings.Contains(err.Error(), "denied by peer") {
+			return nil, fmt.Errorf("服务器拒绝在 %s 上监听，请检查端口是否已被占用，以及服务器 sshd_config 中的 AllowTcpForwarding、GatewayPorts 设置", spec.ListenAddress())
+		}
+		return nil, fmt.Errorf("请求远程监听 %s 失败: %v", spec.ListenAddress(), err)
+	}
+
+	return newForwarder(spec, listener, dialTarget(spec.TargetAddress(), func(address string) (net.Conn, error) {
+		return net.DialTimeout("tcp", address, 10*time.Second)
+	})), nil
+}
+
+// connectFunc 为一个已接受的连接建立到目标地址的连接，返回目标连接和目标地址
+type connectFunc func(local net.Conn) (net.Conn, string, error)
+
+// dialTarget 返回连接固定目标地址的 connectFunc
+func dialTarget(target string, dial func(address string) (net.Conn, error)) connectFunc {
+	return func(local net.Conn) (net.Conn, string, error) {
+		remote, err := dial(target)
+		return remote, target, err
+	}
+}
+
+// newForwarder 创建端口转发器
+func newForwarder(spec ForwardSpec, listener net.Listener, connect connectFunc) *Forwarder {
+	// 监听端口为 0 时，记录实际分配的端口
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok && spec.BindPort == 0 {
+		spec.BindPort = addr.Port
+	}
+
+	return &Forwarder{
+		Spec:     spec,
+		Stats:    &ForwardStats{},
+		listener: listener,
+		connect:  connect,
+		conns:    make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve 接受并转发连接，直到转发器被关闭
+func (f *Forwarder) Serve() error {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			if f.isClosed() {
+				return nil
+			}
+			return fmt.Errorf("接受连接失败: %v", err)
+		}
+
+		if !f.track(conn) {
+			conn.Close()
+			return nil
+		}
+
+		f.wg.Add(1)
+		go f.handle(conn)
+	}
+}
+
+// Close 停止监听并关闭所有正在转发的连接
+func (f *Forwarder) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	err := f.listener.Close()
+	for conn := range f.conns {
+		conn.Close()
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+// isClosed 判断转发器是否已关闭
+func (f *Forwarder) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// track 记录活动连接，转发器已关闭时返回 false
+func (f *Forwarder) track(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+// untrack 移除活动连接
+func (f *Forwarder) untrack(conn net.Conn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.conns, conn)
+}
+
+// logf 输出连接日志
+func (f *Forwarder) logf(format string, args ...any) {
+	if f.Logf != nil {
+		f.Logf(format, args...)
+	}
+}
+
+// handle 转发一个已接受的连接
+func (f *Forwarder) handle(local net.Conn) {
+	defer f.wg.Done()
+	defer f.untrack(local)
+	defer local.Close()
+
+	from := local.RemoteAddr().String()
+
+	remote, target, err := f.connect(local)
+	if err != nil {
+		f.logf("%s -> %s 连接失败: %v", from, target, err)
+		return
+	}
+	if !f.track(remote) {
+		remote.Close()
+		return
+	}
+	defer f.untrack(remote)
+	defer remote.Close()
+
+	f.Stats.Connections.Add(1)
+	f.Stats.Active.Add(1)
+	defer f.Stats.Active.Add(-1)
+
+	f.logf("%s -> %s 已连接", from, target)
+	start := time.Now()
+	sent, received := pipe(local, remote, f.Stats)
+	f.logf("%s -> %s 已断开（发送 %s，接收 %s，用时 %s）",
+		from, target, FormatBytes(sent), FormatBytes(received), time.Since(start).Round(time.Millisecond))
+}
+
+// pipe 在两个连接之间双向复制数据，直到两个方向都结束，并实时累计到 stats
+// 返回从 a 发往 b 和从 b 发往 a 的字节数
+func pipe(a, b net.Conn, stats *ForwardStats) (int64, int64) {
+	var sent, received int64
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		sent, _ = io.Copy(&countingWriter{w: b, n: &stats.BytesSent}, a)
+		closeWrite(b)
+	}()
+	go func() {
+		defer wg.Done()
+		received, _ = io.Copy(&countingWriter{w: a, n: &stats.BytesReceived}, b)
+		closeWrite(a)
+	}()
+
+	wg.Wait()
+	return sent, received
+}
+
+// closeWrite 关闭连接的写方向，通知对端数据已发送完毕
+// 连接不支持半关闭时直接关闭连接
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		if err := cw.CloseWrite(); err == nil || errors.Is(err, net.ErrClosed) {
+			return
+		}
+	}
+	conn.Close()
+}
+
+// countingWriter 在写入的同时累计字节数
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// FormatBytes 将字节数格式化为便于阅读的形式
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
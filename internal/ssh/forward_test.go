@@ -0,0 +1,53 @@
+package ssh
+
+import "testing"
+
+func TestParseForwardSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ForwardSpec
+		wantErr bool
+	}{
+		{spec: "8080:db:5432", want: ForwardSpec{"127.0.0.1", 8080, "db", 5432}},
+		{spec: "0.0.0.0:8080:db:5432", want: ForwardSpec{"0.0.0.0", 8080, "db", 5432}},
+		{spec: ":8080:db:5432", want: ForwardSpec{"", 8080, "db", 5432}},
+		{spec: "0:localhost:80", want: ForwardSpec{"127.0.0.1", 0, "localhost", 80}},
+		{spec: "[::1]:8080:db:5432", want: ForwardSpec{"::1", 8080, "db", 5432}},
+		{spec: "8080:[2001:db8::1]:5432", want: ForwardSpec{"127.0.0.1", 8080, "2001:db8::1", 5432}},
+		{spec: "8080:db", wantErr: true},
+		{spec: "a:b:c:d:e", wantErr: true},
+		{spec: "http:db:5432", wantErr: true},
+		{spec: "8080:db:0", wantErr: true},
+		{spec: "70000:db:5432", wantErr: true},
+		{spec: "8080::5432", wantErr: true},
+		{spec: "[::1:8080:db:5432", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseForwardSpec(tt.spec, "127.0.0.1")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseForwardSpec = %+v，应返回错误", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseForwardSpec 返回错误: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseForwardSpec = %+v，应为 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardSpecAddresses(t *testing.T) {
+	spec := ForwardSpec{BindAddress: "::1", BindPort: 8080, Host: "2001:db8::1", Port: 5432}
+	if got := spec.ListenAddress(); got != "[::1]:8080" {
+		t.Errorf("ListenAddress = %s", got)
+	}
+	if got := spec.TargetAddress(); got != "[2001:db8::1]:5432" {
+		t.Errorf("TargetAddress = %s", got)
+	}
+}
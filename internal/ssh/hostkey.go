@@ -0,0 +1,545 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
+	"goSSH/internal/storage"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 主机密钥校验策略
+const (
+	HostKeyCheckYes = "yes" // 严格校验：未知主机和密钥不匹配均拒绝连接
+	HostKeyCheckAsk = "ask" // 首次连接时询问（TOFU），密钥不匹配时拒绝连接（默认）
+	HostKeyCheckNo  = "no"  // 不校验主机密钥（仅适合临时测试环境）
+)
+
+// HostKeyError 表示主机密钥校验失败
+type HostKeyError struct {
+	Address     string          // 服务器地址 host:port
+	Key         ssh.PublicKey   // 服务器提供的主机密钥
+	Known       []ssh.PublicKey // 已记录的主机密钥（为空表示未知主机）
+	KnownFiles  []string        // 已记录密钥所在的文件
+	Rejected    bool            // 用户拒绝信任或无法询问
+	Description string          // 附加说明
+
+	noTerminal bool // 需要用户确认，但当前不是交互式终端
+}
+
+func (e *HostKeyError) Error() string {
+	fingerprint := ssh.FingerprintSHA256(e.Key)
+
+	if len(e.Known) == 0 {
+		msg := fmt.Sprintf("主机 %s 的密钥未知 (%s %s)", e.Address, e.Key.Type(), fingerprint)
+		if e.Description != "" {
+			msg += "，" + e.Description
+		}
+		return msg
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "主机 %s 的密钥与已记录的不一致，可能存在中间人攻击！\n", e.Address)
+	fmt.Fprintf(&b, "  服务器提供: %s %s\n", e.Key.Type(), fingerprint)
+	for _, k := range e.Known {
+		fmt.Fprintf(&b, "  已记录:     %s %s\n", k.Type(), ssh.FingerprintSHA256(k))
+	}
+	fmt.Fprintf(&b, "  记录文件:   %s\n", strings.Join(e.KnownFiles, ", "))
+	b.WriteString("如确认服务器密钥已更换，请使用 'goss hostkeys scan <名称>' 核对后执行 'goss hostkeys accept <名称>' 更新记录")
+	return b.String()
+}
+
+// KnownHosts 管理 gossh 自有的 known_hosts 文件
+type KnownHosts struct {
+	path string
+}
+
+// NewKnownHosts 打开 gossh 配置目录下的 known_hosts 文件（不存在时自动创建）
+func NewKnownHosts() (*KnownHosts, error) {
+	dir, err := storage.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, "known_hosts")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("创建 known_hosts 文件失败: %v", err)
+	}
+	f.Close()
+
+	return &KnownHosts{path: path}, nil
+}
+
+// Path 返回 known_hosts 文件路径
+func (k *KnownHosts) Path() string {
+	return k.path
+}
+
+// Add 记录服务器的主机密钥
+func (k *KnownHosts) Add(address string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(k.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("打开 known_hosts 文件失败: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{address}, key)); err != nil {
+		return fmt.Errorf("写入 known_hosts 文件失败: %v", err)
+	}
+	return nil
+}
+
+// Lookup 返回已记录的指定地址的主机密钥
+func (k *KnownHosts) Lookup(address string) ([]ssh.PublicKey, error) {
+	lines, err := readLines(k.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 known_hosts 文件失败: %v", err)
+	}
+
+	host := knownhosts.Normalize(address)
+	var keys []ssh.PublicKey
+	for _, line := range lines {
+		hosts, key, ok := parseKnownHostsLine(line)
+		if ok && slices.Contains(hosts, host) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Remove 删除指定地址的所有主机密钥记录，返回删除的记录数
+func (k *KnownHosts) Remove(address string) (int, error) {
+	lines, err := readLines(k.path)
+	if err != nil {
+		return 0, fmt.Errorf("读取 known_hosts 文件失败: %v", err)
+	}
+
+	host := knownhosts.Normalize(address)
+	removed := 0
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		hosts, key, ok := parseKnownHostsLine(line)
+		if !ok || !slices.Contains(hosts, host) {
+			kept = append(kept, line)
+			continue
+		}
+
+		removed++
+		// 一行记录了多个主机时只移除该主机
+		hosts = slices.DeleteFunc(hosts, func(h string) bool { return h == host })
+		if len(hosts) > 0 {
+			kept = append(kept, knownhosts.Line(hosts, key))
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	data := strings.Join(kept, "\n")
+	if len(kept) > 0 {
+		data += "\n"
+	}
+	if err := os.WriteFile(k.path, []byte(data), 0600); err != nil {
+		return 0, fmt.Errorf("写入 known_hosts 文件失败: %v", err)
+	}
+	return removed, nil
+}
+
+// Replace 使用新的主机密钥替换指定地址的所有记录
+func (k *KnownHosts) Replace(address string, keys []ssh.PublicKey) error {
+	if _, err := k.Remove(address); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := k.Add(address, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseKnownHostsLine 解析 known_hosts 中的一行，忽略注释、标记行和哈希主机名
+func parseKnownHostsLine(line string) ([]string, ssh.PublicKey, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") || strings.HasPrefix(line, "|") {
+		return nil, nil, false
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, nil, false
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.Join(fields[1:], " ")))
+	if err != nil {
+		return nil, nil, false
+	}
+	return strings.Split(fields[0], ","), key, true
+}
+
+// files 返回用于校验的 known_hosts 文件列表
+func (k *KnownHosts) files(useSystem bool) []string {
+	files := []string{k.path}
+	if !useSystem {
+		return files
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		systemPath := filepath.Join(home, ".ssh", "known_hosts")
+		if _, err := os.Stat(systemPath); err == nil {
+			files = append(files, systemPath)
+		}
+	}
+	return files
+}
+
+// hostKeyConfig 根据服务器配置构建主机密钥校验回调
+// 同时返回已记录密钥对应的主机密钥算法，使协商优先选择已记录的密钥类型
+func hostKeyConfig(server *models.Server, address string) (ssh.HostKeyCallback, []string, error) {
+	mode := server.StrictHostKeyChecking
+	if mode == "" {
+		mode = HostKeyCheckAsk
+	}
+
+	switch mode {
+	case HostKeyCheckNo:
+		return ssh.InsecureIgnoreHostKey(), nil, nil
+	case HostKeyCheckYes, HostKeyCheckAsk:
+	default:
+		return nil, nil, fmt.Errorf("无效的 strict_host_key_checking 配置: %s（可选值: yes/ask/no）", mode)
+	}
+
+	store, err := NewKnownHosts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := store.files(models.Enabled(server.UseSystemKnownHosts))
+	checker, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 known_hosts 文件失败: %v", err)
+	}
+
+	callback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := checker(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			var revokedErr *knownhosts.RevokedError
+			if errors.As(err, &revokedErr) {
+				return &HostKeyError{Address: hostname, Key: key, Rejected: true, Description: "该密钥已被吊销"}
+			}
+			return err
+		}
+
+		// 密钥不匹配，拒绝连接
+		if len(keyErr.Want) > 0 {
+			hostErr := &HostKeyError{Address: hostname, Key: key}
+			for _, known := range keyErr.Want {
+				hostErr.Known = append(hostErr.Known, known.Key)
+				if !slices.Contains(hostErr.KnownFiles, known.Filename) {
+					hostErr.KnownFiles = append(hostErr.KnownFiles, known.Filename)
+				}
+			}
+			return hostErr
+		}
+
+		// 未知主机
+		if mode == HostKeyCheckYes {
+			return &HostKeyError{
+				Address:     hostname,
+				Key:         key,
+				Rejected:    true,
+				Description: "strict_host_key_checking=yes 时不允许连接未记录的主机",
+			}
+		}
+
+		if err := confirmHostKey(hostname, key); err != nil {
+			return err
+		}
+		return store.Add(hostname, key)
+	}
+
+	algorithms := knownHostKeyAlgorithms(checker, address)
+	if server.HostCAFile == "" {
+		return callback, algorithms, nil
+	}
+
+	// 配置了主机 CA 时接受由其签发的主机证书，并优先请求证书类型的主机密钥
+	caKeys, err := loadHostCAKeys(server.HostCAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(algorithms) > 0 {
+		algorithms = append(slices.Clone(hostCertAlgorithms), algorithms...)
+	}
+	return hostCertCallback(caKeys, callback), algorithms, nil
+}
+
+// confirmHostKey 首次连接时显示主机密钥指纹并询问用户是否信任
+func confirmHostKey(address string, key ssh.PublicKey) error {
+	if !canPrompt() {
+		return &HostKeyError{
+			Address:     address,
+			Key:         key,
+			Rejected:    true,
+			Description: "当前不是交互式终端，无法确认是否信任",
+			noTerminal:  true,
+		}
+	}
+
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	// 提示信息输出到标准错误，避免混入命令的标准输出
+	fmt.Fprintf(os.Stderr, "无法确认主机 %s 的真实性。\n", address)
+	fmt.Fprintf(os.Stderr, "%s 密钥指纹: %s\n", key.Type(), ssh.FingerprintSHA256(key))
+
+	prompt := promptui.Prompt{
+		Label:     "是否信任该主机并继续连接",
+		IsConfirm: true,
+		Stdout:    os.Stderr,
+	}
+	if _, err := prompt.Run(); err != nil {
+		return &HostKeyError{Address: address, Key: key, Rejected: true, Description: "用户拒绝信任"}
+	}
+	return nil
+}
+
+// knownHostKeyAlgorithms 返回已记录的主机密钥对应的算法列表
+// 使用一个随机密钥触发校验失败，从返回的错误中获取该地址已记录的所有密钥
+func knownHostKeyAlgorithms(checker ssh.HostKeyCallback, address string) []string {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil
+	}
+	probe, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil
+	}
+
+	// 校验时优先使用 address，remote 仅为满足接口要求
+	remote := &net.TCPAddr{IP: net.IPv4zero}
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(checker(address, remote, probe), &keyErr) {
+		return nil
+	}
+
+	var algos []string
+	for _, known := range keyErr.Want {
+		for _, algo := range algorithmsForKeyType(known.Key.Type()) {
+			if !slices.Contains(algos, algo) {
+				algos = append(algos, algo)
+			}
+		}
+	}
+	return algos
+}
+
+// algorithmsForKeyType 返回主机密钥类型可用的签名算法
+func algorithmsForKeyType(keyType string) []string {
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+// scanAlgorithms 扫描主机密钥时依次请求的算法，每种密钥类型一个
+var scanAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+}
+
+// errScanDone 用于在获取主机密钥后中止握手
+var errScanDone = errors.New("host key scanned")
+
+// ScanHostKeys 连接服务器并获取其提供的所有类型的主机密钥（不进行认证）
+func ScanHostKeys(server *models.Server) ([]ssh.PublicKey, error) {
+	// 配置了跳板机时，通过跳板机连接目标服务器进行扫描
+	chain, err := resolveJumpChain(server)
+	if err != nil {
+		return nil, err
+	}
+
+	agentConn := connectAgent()
+	if agentConn != nil {
+		defer agentConn.Close()
+	}
+
+	jumps, err := connectJumps(context.Background(), chain, agentConn, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClients(jumps)
+
+	var prev *ssh.Client
+	if len(jumps) > 0 {
+		prev = jumps[len(jumps)-1]
+	}
+
+	var keys []ssh.PublicKey
+	var lastErr error
+	for _, algo := range scanAlgorithms {
+		key, err := scanHostKey(prev, server, algo)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("获取主机密钥失败: %v", lastErr)
+	}
+	return keys, nil
+}
+
+// scanHostKey 使用指定的主机密钥算法进行握手，获取服务器的主机密钥
+// prev 不为 nil 时通过该连接（跳板机）建立TCP连接
+func scanHostKey(prev *ssh.Client, server *models.Server, algo string) (ssh.PublicKey, error) {
+	address := ServerAddress(server)
+
+	// 使用服务器配置的密钥交换和加密算法，以便扫描只支持旧算法的设备
+	algorithms, _, err := algorithmConfig(server)
+	if err != nil {
+		return nil, err
+	}
+
+	var hostKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		Config: algorithms,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errScanDone
+		},
+		HostKeyAlgorithms: []string{algo},
+		Timeout:           5 * time.Second,
+	}
+
+	conn, err := dialVia(context.Background(), prev, server, address, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_, _, _, err = ssh.NewClientConn(conn, address, config)
+	if hostKey != nil {
+		return hostKey, nil
+	}
+	return nil, err
+}
+
+// promptMu 保证同时连接多个服务器时（如多服务器执行命令），交互式询问依次进行
+var promptMu sync.Mutex
+
+// errNoTerminal 表示需要询问用户，但当前不是交互式终端
+var errNoTerminal = errors.New("当前不是交互式终端")
+
+// needsTerminal 判断错误是否因为需要询问用户而当前不是交互式终端
+func needsTerminal(err error) bool {
+	var hostKeyErr *HostKeyError
+	if errors.As(err, &hostKeyErr) {
+		return hostKeyErr.noTerminal
+	}
+	return errors.Is(err, errNoTerminal)
+}
+
+// canPrompt 判断当前是否可以进行交互式询问
+func canPrompt() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// readLines 逐行读取文件内容
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// HostKeyStatus 表示扫描到的主机密钥与已记录密钥的比较结果
+type HostKeyStatus int
+
+const (
+	HostKeyUnchanged HostKeyStatus = iota // 与记录一致
+	HostKeyChanged                        // 同类型密钥已变更
+	HostKeyAdded                          // 服务器提供了未记录的密钥类型
+	HostKeyMissing                        // 已记录的密钥类型服务器不再提供
+)
+
+// HostKeyDiff 表示某一密钥类型的比较结果
+type HostKeyDiff struct {
+	Type    string
+	Status  HostKeyStatus
+	Pinned  ssh.PublicKey // 已记录的密钥（HostKeyAdded 时为 nil）
+	Scanned ssh.PublicKey // 扫描到的密钥（HostKeyMissing 时为 nil）
+}
+
+// DiffHostKeys 按密钥类型比较已记录的密钥和扫描到的密钥
+func DiffHostKeys(pinned, scanned []ssh.PublicKey) []HostKeyDiff {
+	var diffs []HostKeyDiff
+
+	for _, p := range pinned {
+		diff := HostKeyDiff{Type: p.Type(), Status: HostKeyMissing, Pinned: p}
+		for _, s := range scanned {
+			if s.Type() != p.Type() {
+				continue
+			}
+			diff.Scanned = s
+			if keysEqual(p, s) {
+				diff.Status = HostKeyUnchanged
+			} else {
+				diff.Status = HostKeyChanged
+			}
+			break
+		}
+		diffs = append(diffs, diff)
+	}
+
+	for _, s := range scanned {
+		found := slices.ContainsFunc(pinned, func(p ssh.PublicKey) bool { return p.Type() == s.Type() })
+		if !found {
+			diffs = append(diffs, HostKeyDiff{Type: s.Type(), Status: HostKeyAdded, Scanned: s})
+		}
+	}
+
+	return diffs
+}
+
+// keysEqual 判断两个公钥是否相同
+func keysEqual(a, b ssh.PublicKey) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
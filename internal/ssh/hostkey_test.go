@@ -0,0 +1,89 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newEd25519Key 生成一个随机的 ed25519 公钥
+func newEd25519Key(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newECDSAKey 生成一个随机的 ecdsa-sha2-nistp256 公钥
+func newECDSAKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestDiffHostKeys(t *testing.T) {
+	ed1, ed2 := newEd25519Key(t), newEd25519Key(t)
+	ec := newECDSAKey(t)
+
+	tests := []struct {
+		name    string
+		pinned  []ssh.PublicKey
+		scanned []ssh.PublicKey
+		want    map[string]HostKeyStatus
+	}{
+		{"一致", []ssh.PublicKey{ed1}, []ssh.PublicKey{ed1}, map[string]HostKeyStatus{ssh.KeyAlgoED25519: HostKeyUnchanged}},
+		{"已变更", []ssh.PublicKey{ed1}, []ssh.PublicKey{ed2}, map[string]HostKeyStatus{ssh.KeyAlgoED25519: HostKeyChanged}},
+		{"新增类型", []ssh.PublicKey{ed1}, []ssh.PublicKey{ed1, ec}, map[string]HostKeyStatus{
+			ssh.KeyAlgoED25519:  HostKeyUnchanged,
+			ssh.KeyAlgoECDSA256: HostKeyAdded,
+		}},
+		{"不再提供", []ssh.PublicKey{ed1, ec}, []ssh.PublicKey{ed1}, map[string]HostKeyStatus{
+			ssh.KeyAlgoED25519:  HostKeyUnchanged,
+			ssh.KeyAlgoECDSA256: HostKeyMissing,
+		}},
+		{"未记录", nil, []ssh.PublicKey{ec}, map[string]HostKeyStatus{ssh.KeyAlgoECDSA256: HostKeyAdded}},
+		{"都为空", nil, nil, map[string]HostKeyStatus{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := DiffHostKeys(tt.pinned, tt.scanned)
+			if len(diffs) != len(tt.want) {
+				t.Fatalf("得到 %d 个结果，应为 %d 个", len(diffs), len(tt.want))
+			}
+			for _, diff := range diffs {
+				want, ok := tt.want[diff.Type]
+				if !ok {
+					t.Errorf("多余的密钥类型 %s", diff.Type)
+					continue
+				}
+				if diff.Status != want {
+					t.Errorf("%s 的状态为 %d，应为 %d", diff.Type, diff.Status, want)
+				}
+				if (diff.Pinned == nil) != (want == HostKeyAdded) {
+					t.Errorf("%s 的 Pinned = %v", diff.Type, diff.Pinned)
+				}
+				if (diff.Scanned == nil) != (want == HostKeyMissing) {
+					t.Errorf("%s 的 Scanned = %v", diff.Type, diff.Scanned)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"goSSH/internal/config"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// resolveJumpChain 解析服务器的跳板机链，返回按连接顺序排列的跳板机配置
+// 跳板机自身配置了跳板机时会被递归展开
+func resolveJumpChain(server *models.Server) ([]*models.Server, error) {
+	if len(server.Jump) == 0 {
+		return nil, nil
+	}
+
+	manager, err := config.NewManager()
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []*models.Server
+	if err := appendJumps(manager, server, []string{server.Name}, &chain); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// appendJumps 递归展开跳板机，path 记录当前展开路径，用于检测循环引用
+func appendJumps(manager *config.Manager, server *models.Server, path []string, chain *[]*models.Server) error {
+	for _, name := range server.Jump {
+		if slices.Contains(path, name) {
+			return fmt.Errorf("跳板机存在循环引用: %s -> %s", strings.Join(path, " -> "), name)
+		}
+
+		jump, err := manager.GetServer(name)
+		if err != nil {
+			return fmt.Errorf("解析跳板机失败: %v", err)
+		}
+
+		if err := appendJumps(manager, jump, append(path, name), chain); err != nil {
+			return err
+		}
+		*chain = append(*chain, jump)
+	}
+	return nil
+}
+
+// connectJumps 依次连接跳板机链，每一跳都通过上一跳建立连接
+// 返回所有跳板机连接（按连接顺序），调用方负责关闭
+func connectJumps(ctx context.Context, chain []*models.Server, agentConn *agentConnection, timeout time.Duration) ([]*ssh.Client, error) {
+	var clients []*ssh.Client
+	for _, jump := range chain {
+		var prev *ssh.Client
+		if len(clients) > 0 {
+			prev = clients[len(clients)-1]
+		}
+
+		conn, err := dialServer(ctx, prev, jump, agentConn, timeout)
+		if err != nil {
+			closeClients(clients)
+			return nil, fmt.Errorf("连接跳板机 '%s' 失败: %w", jump.Name, err)
+		}
+		clients = append(clients, conn)
+	}
+	return clients, nil
+}
+
+// dialServer 建立到服务器的SSH连接，prev 不为 nil 时通过该连接转发
+// 握手期间 ctx 结束时关闭连接并返回 ctx 的错误
+func dialServer(ctx context.Context, prev *ssh.Client, server *models.Server, agentConn *agentConnection, timeout time.Duration) (*ssh.Client, error) {
+	config, err := newClientConfig(server, agentConn, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	address := ServerAddress(server)
+	netConn, err := dialVia(ctx, prev, server, address, timeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, address, config)
+	if !stop() {
+		if err == nil {
+			conn.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		netConn.Close()
+		if isAuthFailure(err) {
+			return nil, &AuthError{Address: address, Err: err}
+		}
+		return nil, err
+	}
+	return ssh.NewClient(conn, chans, reqs), nil
+}
+
+// dialVia 建立到 address 的 TCP 连接
+// prev 不为 nil 时通过该SSH连接转发（direct-tcpip），否则直接连接或通过服务器配置的代理连接
+func dialVia(ctx context.Context, prev *ssh.Client, server *models.Server, address string, timeout time.Duration) (net.Conn, error) {
+	if prev != nil {
+		return prev.DialContext(ctx, "tcp", address)
+	}
+	return dialDirect(ctx, server, address, timeout)
+}
+
+// closeClients 按连接的相反顺序关闭SSH连接
+func closeClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
+}
@@ -0,0 +1,140 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// 保活默认值
+const (
+	DefaultKeepaliveInterval = 30 // 默认保活间隔（秒）
+	DefaultKeepaliveCountMax = 3  // 默认允许连续未响应的次数
+)
+
+// ErrKeepaliveTimeout 表示服务器连续多次未响应保活请求，连接已被关闭
+var ErrKeepaliveTimeout = errors.New("服务器未响应保活请求，连接已断开")
+
+// keepaliveConfig 返回服务器的保活间隔和允许连续未响应的次数
+// 间隔小于 0 表示关闭保活
+func keepaliveConfig(server *models.Server) (time.Duration, int) {
+	interval := server.KeepaliveInterval
+	if interval == 0 {
+		interval = DefaultKeepaliveInterval
+	}
+
+	countMax := server.KeepaliveCountMax
+	if countMax <= 0 {
+		countMax = DefaultKeepaliveCountMax
+	}
+	return time.Duration(interval) * time.Second, countMax
+}
+
+// keepalive 定期发送 keepalive@openssh.com 请求，连续 countMax 次未在间隔内收到响应时关闭连接
+// 服务器对该请求返回成功或失败都视为连接正常；因超时关闭连接时设置 timedOut
+func keepalive(conn *ssh.Client, interval time.Duration, countMax int, stop <-chan struct{}, timedOut *atomic.Bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+
+		select {
+		case <-stop:
+			return
+		case err := <-reply:
+			if err != nil {
+				// 连接已断开，由 Wait 返回
+				return
+			}
+			missed = 0
+		case <-time.After(interval):
+			missed++
+			if missed >= countMax {
+				timedOut.Store(true)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// Backoff 指数退避，用于计算重连的等待时间
+type Backoff struct {
+	Initial time.Duration // 首次等待时间
+	Max     time.Duration // 最长等待时间
+
+	attempt int
+}
+
+// NewBackoff 创建默认的指数退避（1 秒起，每次翻倍，最长 1 分钟）
+func NewBackoff() *Backoff {
+	return &Backoff{Initial: time.Second, Max: time.Minute}
+}
+
+// Next 返回下一次重试前的等待时间，并加入 ±20% 的随机抖动，避免多个连接同时重连
+func (b *Backoff) Next() time.Duration {
+	delay := b.Initial << b.attempt
+	if delay <= 0 || delay > b.Max {
+		delay = b.Max
+	} else {
+		b.attempt++
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5*2+1)) - delay/5
+	return delay + jitter
+}
+
+// Reset 重置退避状态
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
+
+// ReconnectWithBackoff 按指数退避不断尝试重新连接，直到成功或 ctx 被取消
+// 每次失败后调用 notify（可以为 nil），参数为失败次数、下次重试前的等待时间和失败原因
+// 认证失败、主机密钥校验失败或客户端已关闭时不再重试，直接返回错误，避免反复尝试错误的凭据
+func (c *Client) ReconnectWithBackoff(ctx context.Context, notify func(attempt int, delay time.Duration, err error)) error {
+	backoff := NewBackoff()
+	for attempt := 1; ; attempt++ {
+		err := c.Reconnect(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var authErr *AuthError
+		var hostKeyErr *HostKeyError
+		if errors.As(err, &authErr) || errors.As(err, &hostKeyErr) || errors.Is(err, errClientClosed) {
+			return err
+		}
+
+		delay := backoff.Next()
+		if notify != nil {
+			notify(attempt, delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"goSSH/internal/secret"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// otpKeywords 用于识别一次性密码问题的关键字（小写）
+var otpKeywords = []string{"verification code", "one-time", "otp", "token", "2fa", "two-factor", "authenticator", "passcode", "验证码", "动态码", "动态口令", "动态密码", "一次性密码"}
+
+// passwordKeywords 用于识别密码问题的关键字（小写），同时包含一次性密码关键字的问题不算密码问题
+var passwordKeywords = []string{"password", "密码"}
+
+// keyboardInteractive 返回 keyboard-interactive 认证的应答函数
+// 密码问题使用已配置的密码自动回答（仅第一次，避免密码错误时反复提交），
+// 一次性密码问题在配置了 TOTP 种子时自动计算，其余问题通过终端询问用户，不回显的问题输入时以 * 显示
+// 当前不是交互式终端且有问题无法自动回答时返回错误，不会等待输入
+func keyboardInteractive(server *models.Server, password string) ssh.KeyboardInteractiveChallenge {
+	passwordUsed := false
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		shown := false
+
+		for i, question := range questions {
+			lower := strings.ToLower(question)
+
+			// 先识别一次性密码问题，如 "One-time password (OATH) for `user':" 也包含 password
+			if containsAny(lower, otpKeywords) {
+				code, ok, err := totpCode(server)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					answers[i] = code
+					continue
+				}
+			} else if password != "" && !passwordUsed && containsAny(lower, passwordKeywords) {
+				answers[i] = password
+				passwordUsed = true
+				continue
+			}
+
+			if !canPrompt() {
+				err := fmt.Errorf("服务器要求交互式认证（%s），但%w；可以配置 totp_secret_ref 自动回答一次性密码", strings.TrimSpace(question), errNoTerminal)
+				return nil, &AuthError{Address: ServerAddress(server), Err: err}
+			}
+
+			// 第一次需要询问时显示服务器提供的说明
+			if !shown {
+				promptMu.Lock()
+				defer promptMu.Unlock()
+				if name != "" {
+					fmt.Fprintln(os.Stderr, name)
+				}
+				if instruction != "" {
+					fmt.Fprintln(os.Stderr, instruction)
+				}
+				shown = true
+			}
+
+			prompt := promptui.Prompt{
+				Label:  strings.TrimRight(strings.TrimSpace(question), ":："),
+				Stdout: os.Stderr,
+			}
+			if !echos[i] {
+				prompt.Mask = '*'
+			}
+			input, err := prompt.Run()
+			if err != nil {
+				return nil, fmt.Errorf("输入取消: %v", err)
+			}
+			answers[i] = input
+		}
+		return answers, nil
+	}
+}
+
+// totpCode 根据服务器配置的 TOTP 种子计算当前的一次性密码，未配置种子时返回 false
+func totpCode(server *models.Server) (string, bool, error) {
+	seed := server.TOTPSecret
+	if server.TOTPSecretRef != "" {
+		resolved, err := secret.Resolve(server.TOTPSecretRef)
+		if err != nil {
+			return "", false, err
+		}
+		seed = resolved
+	}
+	if seed == "" {
+		return "", false, nil
+	}
+
+	code, err := secret.TOTP(strings.TrimSpace(seed), time.Now())
+	if err != nil {
+		return "", false, fmt.Errorf("计算服务器 '%s' 的一次性密码失败: %v", server.Name, err)
+	}
+	return code, true, nil
+}
+
+// containsAny 判断 s 是否包含任意一个关键字
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
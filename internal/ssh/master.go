@@ -0,0 +1,322 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"goSSH/internal/daemon"
+	"goSSH/internal/storage"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultControlPersist 主连接默认的空闲超时（秒）
+const DefaultControlPersist = 600
+
+// 主连接进程支持的请求
+const (
+	masterCmdConnect = "connect" // 连接服务器，响应后该连接转为 SSH 协议
+	masterCmdStatus  = "status"  // 查询状态
+	masterCmdStop    = "stop"    // 关闭连接
+)
+
+// MasterStatus 表示主连接进程中一个服务器连接的状态
+type MasterStatus struct {
+	Server        string    `json:"server"`
+	Clients       int       `json:"clients"`        // 正在使用该连接的 goss 进程数
+	Channels      int64     `json:"channels"`       // 活动的会话和通道数
+	TotalChannels int64     `json:"total_channels"` // 累计打开的会话和通道数
+	Since         time.Time `json:"since"`          // 连接建立的时间
+	IdleSince     time.Time `json:"idle_since"`     // 开始空闲的时间，使用中为零值
+	IdleTimeout   int       `json:"idle_timeout"`   // 空闲超时（秒）
+}
+
+// masterRequest 发送给主连接进程的请求
+type masterRequest struct {
+	Command string         `json:"command"`
+	Servers []string       `json:"servers,omitempty"`
+	Server  *models.Server `json:"server,omitempty"` // connect 请求的服务器配置，主连接进程使用它连接服务器
+}
+
+// masterResponse 主连接进程的响应
+type masterResponse struct {
+	Error       string         `json:"error,omitempty"`
+	NoTerminal  bool           `json:"no_terminal,omitempty"` // 连接服务器时需要询问用户，主连接进程无法询问
+	Unavailable bool           `json:"unavailable,omitempty"` // 主连接进程无法提供该连接，客户端应直接连接
+	AuthFailed  bool           `json:"auth_failed,omitempty"` // 连接服务器时认证失败
+	HostKey     *masterHostKey `json:"host_key,omitempty"`    // 连接服务器时主机密钥校验失败
+	Stopped     []string       `json:"stopped,omitempty"`
+	Connections []MasterStatus `json:"connections,omitempty"`
+}
+
+// masterHostKey 是通过套接字传递的 HostKeyError
+type masterHostKey struct {
+	Address     string   `json:"address"`
+	Key         []byte   `json:"key"`
+	Known       [][]byte `json:"known,omitempty"`
+	KnownFiles  []string `json:"known_files,omitempty"`
+	Rejected    bool     `json:"rejected,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// setError 记录连接服务器失败的原因，并保留错误的类型，使客户端得到与直接连接相同的错误
+func (r *masterResponse) setError(err error) {
+	r.Error = err.Error()
+	r.NoTerminal = needsTerminal(err)
+
+	var unavailable *masterUnavailableError
+	r.Unavailable = errors.As(err, &unavailable)
+
+	var authErr *AuthError
+	r.AuthFailed = errors.As(err, &authErr)
+
+	var hostKeyErr *HostKeyError
+	if errors.As(err, &hostKeyErr) {
+		r.HostKey = &masterHostKey{
+			Address:     hostKeyErr.Address,
+			Key:         hostKeyErr.Key.Marshal(),
+			KnownFiles:  hostKeyErr.KnownFiles,
+			Rejected:    hostKeyErr.Rejected,
+			Description: hostKeyErr.Description,
+		}
+		for _, key := range hostKeyErr.Known {
+			r.HostKey.Known = append(r.HostKey.Known, key.Marshal())
+		}
+	}
+}
+
+// connectError 还原主连接进程连接服务器失败的错误
+func (r *masterResponse) connectError(server *models.Server) error {
+	if r.HostKey != nil {
+		if hostKeyErr := r.HostKey.hostKeyError(); hostKeyErr != nil {
+			return fmt.Errorf("连接服务器失败: %w", hostKeyErr)
+		}
+	}
+	if r.AuthFailed {
+		return &AuthError{Address: ServerAddress(server), Err: errors.New(r.Error)}
+	}
+	return errors.New(r.Error)
+}
+
+// hostKeyError 还原 HostKeyError，密钥无法解析时返回 nil
+func (k *masterHostKey) hostKeyError() *HostKeyError {
+	key, err := ssh.ParsePublicKey(k.Key)
+	if err != nil {
+		return nil
+	}
+	hostKeyErr := &HostKeyError{
+		Address:     k.Address,
+		Key:         key,
+		KnownFiles:  k.KnownFiles,
+		Rejected:    k.Rejected,
+		Description: k.Description,
+	}
+	for _, data := range k.Known {
+		known, err := ssh.ParsePublicKey(data)
+		if err != nil {
+			return nil
+		}
+		hostKeyErr.Known = append(hostKeyErr.Known, known)
+	}
+	return hostKeyErr
+}
+
+// masterUnavailableError 表示无法通过主连接进程建立连接，应直接连接服务器
+// 包括主连接进程无法启动或无法通信，连接服务器时需要询问用户（如确认主机密钥）而主连接进程无法询问，
+// 以及主连接进程中已有的连接与请求的服务器配置不一致
+type masterUnavailableError struct {
+	err error
+}
+
+func (e *masterUnavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *masterUnavailableError) Unwrap() error {
+	return e.err
+}
+
+// MasterSocketPath 返回主连接进程的套接字路径
+func MasterSocketPath() (string, error) {
+	dir, err := storage.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "master.sock"), nil
+}
+
+// MasterLogPath 返回主连接进程的日志文件路径
+func MasterLogPath() (string, error) {
+	dir, err := storage.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "master.log"), nil
+}
+
+// MasterConnections 查询主连接进程中的连接状态
+// 返回 false 表示主连接进程未运行
+func MasterConnections() ([]MasterStatus, bool, error) {
+	path, err := MasterSocketPath()
+	if err != nil {
+		return nil, false, err
+	}
+	if !daemon.IsRunning(path) {
+		return nil, false, nil
+	}
+
+	resp, err := sendMaster(path, masterRequest{Command: masterCmdStatus})
+	if err != nil {
+		return nil, true, err
+	}
+	return resp.Connections, true, nil
+}
+
+// StopMaster 关闭主连接进程中指定服务器的连接，servers 为空时关闭所有连接并退出主连接进程
+// 返回已关闭的服务器列表；返回 false 表示主连接进程未运行
+func StopMaster(servers []string) ([]string, bool, error) {
+	path, err := MasterSocketPath()
+	if err != nil {
+		return nil, false, err
+	}
+	if !daemon.IsRunning(path) {
+		return nil, false, nil
+	}
+
+	resp, err := sendMaster(path, masterRequest{Command: masterCmdStop, Servers: servers})
+	if err != nil {
+		return nil, true, err
+	}
+	return resp.Stopped, true, nil
+}
+
+// sendMaster 向主连接进程发送请求并等待响应
+func sendMaster(path string, req masterRequest) (*masterResponse, error) {
+	conn, err := daemon.Dial(path)
+	if err != nil {
+		return nil, fmt.Errorf("连接主连接进程失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+
+	var resp masterResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// useMaster 判断是否通过主连接进程建立连接
+// agent 转发需要将服务器打开的通道转回当前进程，不经过主连接进程
+func (c *Client) useMaster() bool {
+	return models.Enabled(c.server.ControlMaster) && !c.noMaster && !c.server.ForwardAgent
+}
+
+// connectMaster 通过主连接进程连接服务器，主连接进程未运行时自动启动
+// 主连接进程在本地套接字上运行一个 SSH 服务端，将会话和通道转发到它持有的服务器连接
+// ctx 结束时关闭与主连接进程的连接，主连接进程会继续完成与服务器的连接供下次使用
+// 主连接进程不可用时返回 *masterUnavailableError，主连接进程连接服务器失败时返回与直接连接相同类型的错误
+func (c *Client) connectMaster(ctx context.Context) error {
+	path, err := MasterSocketPath()
+	if err != nil {
+		return &masterUnavailableError{err}
+	}
+
+	if !daemon.IsRunning(path) {
+		if err := startMaster(path); err != nil {
+			return &masterUnavailableError{err}
+		}
+	}
+
+	conn, err := daemon.Dial(path)
+	if err != nil {
+		return &masterUnavailableError{fmt.Errorf("连接主连接进程失败: %v", err)}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// 主连接进程首次连接服务器时可能需要经过多个跳板机
+	conn.SetDeadline(time.Now().Add(time.Minute))
+	if err := json.NewEncoder(conn).Encode(masterRequest{Command: masterCmdConnect, Server: c.server}); err != nil {
+		conn.Close()
+		return &masterUnavailableError{fmt.Errorf("发送请求失败: %v", err)}
+	}
+
+	// 响应之后紧接着是 SSH 协议数据，需要保留读取缓冲区中多读的部分
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return &masterUnavailableError{fmt.Errorf("读取响应失败: %v", err)}
+	}
+	var resp masterResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		conn.Close()
+		return &masterUnavailableError{fmt.Errorf("读取响应失败: %v", err)}
+	}
+	if resp.Error != "" {
+		conn.Close()
+		if resp.NoTerminal || resp.Unavailable {
+			return &masterUnavailableError{errors.New(resp.Error)}
+		}
+		return resp.connectError(c.server)
+	}
+
+	// 套接字仅当前用户可访问，无需校验主连接进程的临时主机密钥
+	config := &ssh.ClientConfig{
+		User:            c.server.Name,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(&bufferedConn{Conn: conn, reader: reader}, "goss-master", config)
+	if err != nil {
+		conn.Close()
+		return &masterUnavailableError{fmt.Errorf("与主连接进程握手失败: %v", err)}
+	}
+	conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	if !stop() {
+		client.Close()
+		return ctx.Err()
+	}
+	state, err := c.setConn(client, nil)
+	if err != nil {
+		return err
+	}
+
+	// 保活由主连接进程负责，服务器连接断开时主连接进程会关闭本连接
+	go c.monitor(client, state, false)
+	return nil
+}
+
+// startMaster 启动主连接进程，已解锁的主密码密钥通过标准输入传递
+func startMaster(path string) error {
+	st, err := storage.NewStorage()
+	if err != nil {
+		return err
+	}
+
+	key, err := st.UnlockedKey()
+	if err != nil {
+		return err
+	}
+
+	input := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err := daemon.Start(input, "master", "daemon"); err != nil {
+		return err
+	}
+	return daemon.WaitRunning(path, 5*time.Second)
+}
@@ -0,0 +1,432 @@
+package ssh
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"goSSH/internal/daemon"
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// master 主连接进程，为每个服务器持有一个已认证的SSH连接，供其他 goss 进程复用
+type master struct {
+	mu       sync.Mutex
+	conns    map[string]*masterConn // 按服务器名称
+	config   *ssh.ServerConfig
+	logger   *log.Logger
+	listener net.Listener
+}
+
+// masterConn 表示主连接进程持有的一个服务器连接
+type masterConn struct {
+	server    string
+	config    *models.Server // 建立连接时使用的服务器配置
+	client    *Client
+	clients   map[*ssh.ServerConn]bool // 正在使用该连接的 goss 进程
+	channels  int64
+	total     int64
+	since     time.Time
+	idleSince time.Time
+	timeout   time.Duration
+	timer     *time.Timer // 空闲超时计时器
+	closed    bool
+}
+
+// RunMaster 运行主连接进程，在本地套接字上接受其他 goss 进程的请求
+// 该函数会阻塞，直到所有连接都已关闭（空闲超时、服务器断开或 goss master stop）
+func RunMaster() error {
+	path, err := MasterSocketPath()
+	if err != nil {
+		return err
+	}
+
+	logPath, err := MasterLogPath()
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %v", err)
+	}
+	defer logFile.Close()
+
+	// 本地 SSH 服务端使用临时主机密钥，访问控制依赖套接字的文件权限
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("生成主机密钥失败: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return fmt.Errorf("生成主机密钥失败: %v", err)
+	}
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := daemon.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	m := &master{
+		conns:    make(map[string]*masterConn),
+		config:   serverConfig,
+		logger:   log.New(logFile, "", log.LstdFlags),
+		listener: listener,
+	}
+	m.logger.Printf("主连接进程已启动 (pid %d)", os.Getpid())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			m.logger.Printf("主连接进程退出")
+			return nil
+		}
+		go m.handle(conn)
+	}
+}
+
+// handle 处理一个请求；connect 请求成功后该连接转为 SSH 协议，直到客户端断开
+func (m *master) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req masterRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return
+	}
+
+	var resp masterResponse
+	switch req.Command {
+	case masterCmdConnect:
+		if req.Server == nil || req.Server.Name == "" {
+			resp.Error = "请求中缺少服务器配置"
+			break
+		}
+		mc, err := m.connect(req.Server)
+		if err != nil {
+			resp.setError(err)
+			break
+		}
+		if err := json.NewEncoder(conn).Encode(resp); err != nil {
+			m.release(mc, nil)
+			return
+		}
+		m.serve(mc, &bufferedConn{Conn: conn, reader: reader})
+		return
+	case masterCmdStatus:
+		resp.Connections = m.status()
+	case masterCmdStop:
+		resp.Stopped = m.stop(req.Servers)
+	default:
+		resp.Error = fmt.Sprintf("未知的请求: %s", req.Command)
+	}
+
+	json.NewEncoder(conn).Encode(resp)
+
+	// 响应发送后再退出，未指定服务器的 stop 请求总是使主连接进程退出
+	if req.Command == masterCmdStop {
+		m.mu.Lock()
+		if len(req.Servers) == 0 {
+			m.listener.Close()
+		} else {
+			m.exitIfEmpty()
+		}
+		m.mu.Unlock()
+	}
+}
+
+// connect 返回服务器的连接，尚未连接时使用请求中的服务器配置建立连接；返回的连接已计入使用者
+// 不读取配置文件，调用方传入未保存的配置时也使用该配置连接
+func (m *master) connect(server *models.Server) (*masterConn, error) {
+	name := server.Name
+	m.mu.Lock()
+	if mc := m.conns[name]; mc != nil {
+		defer m.mu.Unlock()
+		return m.reuse(mc, server)
+	}
+	m.mu.Unlock()
+
+	// 连接服务器时不持有锁，避免阻塞其他请求
+	client := NewClient(server)
+	client.noMaster = true
+	if err := client.Connect(); err != nil {
+		m.logger.Printf("[%s] 连接失败: %v", name, err)
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mc := m.conns[name]; mc != nil {
+		// 连接期间已有其他请求建立了连接，使用已有的连接
+		client.Close()
+		return m.reuse(mc, server)
+	}
+
+	timeout := server.ControlPersist
+	if timeout <= 0 {
+		timeout = DefaultControlPersist
+	}
+	mc := &masterConn{
+		server:  name,
+		config:  server,
+		client:  client,
+		clients: make(map[*ssh.ServerConn]bool),
+		since:   time.Now(),
+		timeout: time.Duration(timeout) * time.Second,
+	}
+	m.conns[name] = mc
+	m.acquire(mc)
+	m.logger.Printf("[%s] 已连接", name)
+
+	go m.watch(mc)
+	return mc, nil
+}
+
+// reuse 在配置一致时使用已有的连接，调用方需持有锁
+// 配置不一致（如配置已修改，或调用方传入了未保存的配置）时返回 *masterUnavailableError，由客户端直接连接
+func (m *master) reuse(mc *masterConn, server *models.Server) (*masterConn, error) {
+	if !reflect.DeepEqual(mc.config, server) {
+		return nil, &masterUnavailableError{fmt.Errorf("服务器 '%s' 的配置与主连接进程中的连接不一致", server.Name)}
+	}
+	m.acquire(mc)
+	return mc, nil
+}
+
+// acquire 增加连接的使用者并停止空闲计时，调用方需持有锁
+// 使用者在完成 SSH 握手前以 nil 占位
+func (m *master) acquire(mc *masterConn) {
+	mc.clients[nil] = true
+	mc.idleSince = time.Time{}
+	if mc.timer != nil {
+		mc.timer.Stop()
+		mc.timer = nil
+	}
+}
+
+// release 移除连接的使用者，没有使用者时开始空闲计时
+func (m *master) release(mc *masterConn, sconn *ssh.ServerConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(mc.clients, sconn)
+	if len(mc.clients) > 0 || mc.closed {
+		return
+	}
+
+	mc.idleSince = time.Now()
+	mc.timer = time.AfterFunc(mc.timeout, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if len(mc.clients) == 0 && !mc.closed {
+			m.logger.Printf("[%s] 空闲超时", mc.server)
+			m.close(mc)
+			m.exitIfEmpty()
+		}
+	})
+}
+
+// serve 在本地连接上运行 SSH 服务端，将客户端打开的会话和通道转发到服务器连接
+func (m *master) serve(mc *masterConn, conn net.Conn) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, m.config)
+	if err != nil {
+		m.release(mc, nil)
+		return
+	}
+
+	m.mu.Lock()
+	delete(mc.clients, nil)
+	mc.clients[sconn] = true
+	closed := mc.closed
+	m.mu.Unlock()
+	if closed {
+		sconn.Close()
+	}
+
+	// 全局请求（如保活）直接回复，不转发到服务器
+	go ssh.DiscardRequests(reqs)
+
+	upstream := mc.client.GetConnection()
+	for newChannel := range chans {
+		go func(newChannel ssh.NewChannel) {
+			m.mu.Lock()
+			mc.channels++
+			mc.total++
+			m.mu.Unlock()
+
+			proxyChannel(newChannel, upstream)
+
+			m.mu.Lock()
+			mc.channels--
+			m.mu.Unlock()
+		}(newChannel)
+	}
+
+	sconn.Close()
+	m.release(mc, sconn)
+}
+
+// watch 等待服务器连接断开，断开后关闭所有使用该连接的本地连接
+func (m *master) watch(mc *masterConn) {
+	<-mc.client.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mc.closed {
+		return
+	}
+	m.logger.Printf("[%s] 连接已断开: %v", mc.server, mc.client.Err())
+	m.close(mc)
+	m.exitIfEmpty()
+}
+
+// close 关闭服务器连接及使用它的本地连接，调用方需持有锁
+func (m *master) close(mc *masterConn) {
+	mc.closed = true
+	if mc.timer != nil {
+		mc.timer.Stop()
+	}
+	for sconn := range mc.clients {
+		if sconn != nil {
+			sconn.Close()
+		}
+	}
+	mc.client.Close()
+	delete(m.conns, mc.server)
+	m.logger.Printf("[%s] 连接已关闭", mc.server)
+}
+
+// exitIfEmpty 没有服务器连接时停止监听，主连接进程随之退出；调用方需持有锁
+func (m *master) exitIfEmpty() {
+	if len(m.conns) == 0 {
+		m.listener.Close()
+	}
+}
+
+// stop 关闭指定服务器的连接，servers 为空时关闭所有连接；返回已关闭的服务器列表
+func (m *master) stop(servers []string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(servers) == 0 {
+		for name := range m.conns {
+			servers = append(servers, name)
+		}
+	}
+
+	var stopped []string
+	for _, name := range servers {
+		if mc := m.conns[name]; mc != nil {
+			m.close(mc)
+			stopped = append(stopped, name)
+		}
+	}
+	sort.Strings(stopped)
+	return stopped
+}
+
+// status 返回所有连接的状态，按服务器名称排序
+func (m *master) status() []MasterStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []MasterStatus
+	for _, mc := range m.conns {
+		list = append(list, MasterStatus{
+			Server:        mc.server,
+			Clients:       len(mc.clients),
+			Channels:      mc.channels,
+			TotalChannels: mc.total,
+			Since:         mc.since,
+			IdleSince:     mc.idleSince,
+			IdleTimeout:   int(mc.timeout / time.Second),
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Server < list[j].Server
+	})
+	return list
+}
+
+// proxyChannel 在服务器连接上打开同类型的通道，并双向转发数据和通道请求
+func proxyChannel(newChannel ssh.NewChannel, upstream *ssh.Client) {
+	remote, remoteReqs, err := upstream.OpenChannel(newChannel.ChannelType(), newChannel.ExtraData())
+	if err != nil {
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			newChannel.Reject(openErr.Reason, openErr.Message)
+		} else {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		}
+		return
+	}
+
+	local, localReqs, err := newChannel.Accept()
+	if err != nil {
+		remote.Close()
+		return
+	}
+
+	// 客户端关闭通道时关闭服务器端的通道
+	go func() {
+		forwardRequests(localReqs, remote)
+		remote.Close()
+	}()
+
+	go func() {
+		io.Copy(remote, local)
+		remote.CloseWrite()
+	}()
+
+	reqsDone := make(chan struct{})
+	go func() {
+		forwardRequests(remoteReqs, local)
+		close(reqsDone)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(local, remote)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(local.Stderr(), remote.Stderr())
+	}()
+
+	// 服务器关闭通道后，等待输出和退出状态等请求都已转发再关闭本地通道
+	wg.Wait()
+	local.CloseWrite()
+	<-reqsDone
+	local.Close()
+}
+
+// forwardRequests 将通道请求转发到另一端，并把对端的回复返回给请求方
+func forwardRequests(reqs <-chan *ssh.Request, channel ssh.Channel) {
+	for req := range reqs {
+		ok, err := channel.SendRequest(req.Type, req.WantReply, req.Payload)
+		if req.WantReply {
+			req.Reply(ok && err == nil, nil)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"goSSH/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// HostResult 表示在一个服务器上执行命令的结果
+type HostResult struct {
+	Server   models.Server
+	ExitCode int           // 远程命令的退出码，命令未能执行（如连接失败）时为 -1
+	Signal   string        // 终止远程命令的信号（如 TERM），正常退出时为空
+	Start    time.Time     // 开始连接的时间
+	End      time.Time     // 命令结束或执行失败的时间
+	Duration time.Duration // 从开始连接到命令结束的耗时
+	Err      error         // 连接失败、命令以非零状态退出等错误，成功时为 nil
+}
+
+// ExecRecord 表示在一个服务器上执行命令的结构化结果，用于 JSON 输出和在 Go 程序中使用
+type ExecRecord struct {
+	Server     string    `json:"server"`           // 服务器名称
+	Host       string    `json:"host"`             // 服务器地址（host:port）
+	Command    string    `json:"command"`          // 执行的命令
+	Stdout     string    `json:"stdout"`           // 标准输出
+	Stderr     string    `json:"stderr"`           // 错误输出
+	ExitStatus *int      `json:"exit_status"`      // 退出码（被信号终止时为 128+信号值），命令未能执行时为 null
+	Signal     string    `json:"signal,omitempty"` // 终止命令的信号（如 TERM）
+	Start      time.Time `json:"start"`            // 开始时间
+	End        time.Time `json:"end"`              // 结束时间
+	Error      string    `json:"error,omitempty"`  // 连接、认证等导致命令未能执行的错误
+	Err        error     `json:"-"`                // 原始错误（包括命令以非零状态退出时的 *ssh.ExitError），可以传给 ExitCode
+}
+
+// ParallelOptions 是在多个服务器上执行命令的选项
+type ParallelOptions struct {
+	Parallel int           // 最多同时处理的服务器数量，小于等于 0 表示不限制
+	Timeout  time.Duration // 每个服务器上命令的最长执行时间（从开始连接算起），0 表示不限制
+	Relay    *SignalRelay  // 不为 nil 时，本地收到的信号会转发给所有正在执行的远程命令
+	Stdin    io.Reader     // 不为 nil 时，每个服务器上的命令都会从头读到其中的完整数据
+}
+
+// ExecuteParallel 在多个服务器上并发执行命令
+// output 返回每个服务器的标准输出和错误输出的写入位置，会在该服务器开始执行前调用
+// 返回的结果与 servers 的顺序一致
+func ExecuteParallel(servers []models.Server, command string, opts ParallelOptions, output func(server *models.Server) (stdout, stderr io.Writer)) []HostResult {
+	return executeParallel(servers, command, opts, func(i int) (io.Writer, io.Writer) {
+		return output(&servers[i])
+	}, nil)
+}
+
+// CollectParallel 在多个服务器上并发执行命令并收集输出，返回每个服务器的结构化结果（与 servers 的顺序一致）
+// done 不为 nil 时，每个服务器执行结束后立即以其结果调用 done，多次调用不会同时进行
+func CollectParallel(servers []models.Server, command string, opts ParallelOptions, done func(record ExecRecord)) []ExecRecord {
+	stdouts := make([]bytes.Buffer, len(servers))
+	stderrs := make([]bytes.Buffer, len(servers))
+	records := make([]ExecRecord, len(servers))
+
+	var mu sync.Mutex
+	executeParallel(servers, command, opts,
+		func(i int) (io.Writer, io.Writer) {
+			return &stdouts[i], &stderrs[i]
+		},
+		func(i int, result HostResult) {
+			records[i] = newExecRecord(result, command, stdouts[i].String(), stderrs[i].String())
+			if done != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				done(records[i])
+			}
+		})
+	return records
+}
+
+// newExecRecord 将执行结果转换为结构化记录
+func newExecRecord(result HostResult, command, stdout, stderr string) ExecRecord {
+	record := ExecRecord{
+		Server:  result.Server.Name,
+		Host:    ServerAddress(&result.Server),
+		Command: command,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Signal:  result.Signal,
+		Start:   result.Start,
+		End:     result.End,
+		Err:     result.Err,
+	}
+
+	// 命令以非零状态退出时退出码已经说明了结果，只有命令未能执行时才记录错误
+	if result.ExitCode >= 0 {
+		exitCode := result.ExitCode
+		record.ExitStatus = &exitCode
+	} else if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	return record
+}
+
+// DefaultParallel 在多个服务器上执行操作时默认的最大并发数
+const DefaultParallel = 10
+
+// RunParallel 为 0 到 n-1 的每个下标调用 fn，最多同时进行 parallel 个，全部结束后返回
+// 按下标顺序开始调用；parallel 小于等于 0 时不限制
+func RunParallel(n, parallel int, fn func(i int)) {
+	if parallel <= 0 || parallel > n {
+		parallel = n
+	}
+
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		// 按顺序占用并发名额，保证按下标顺序开始
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// executeParallel 并发执行命令，output 按服务器下标返回输出的写入位置，done 不为 nil 时在每个服务器执行结束后调用
+func executeParallel(servers []models.Server, command string, opts ParallelOptions, output func(i int) (io.Writer, io.Writer), done func(i int, result HostResult)) []HostResult {
+	var stdins []*teeReader
+	if opts.Stdin != nil {
+		stdins = newStdinTee(opts.Stdin, len(servers))
+	}
+
+	results := make([]HostResult, len(servers))
+	RunParallel(len(servers), opts.Parallel, func(i int) {
+		execOpts := ExecOptions{Relay: opts.Relay}
+		execOpts.Stdout, execOpts.Stderr = output(i)
+		var stdinErr error
+		if stdins != nil {
+			execOpts.Stdin = stdins[i]
+			defer stdins[i].Close()
+			stdinErr = stdins[i].start()
+		}
+		if stdinErr != nil {
+			now := time.Now()
+			results[i] = HostResult{Server: servers[i], ExitCode: -1, Start: now, End: now, Err: stdinErr}
+		} else {
+			results[i] = executeOn(&servers[i], command, opts, execOpts)
+		}
+		if done != nil {
+			done(i, results[i])
+		}
+	})
+	return results
+}
+
+// executeOn 连接服务器并执行命令，返回执行结果
+func executeOn(server *models.Server, command string, opts ParallelOptions, execOpts ExecOptions) HostResult {
+	result := HostResult{Server: *server, ExitCode: -1, Start: time.Now()}
+
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	client := NewClient(server)
+	defer client.Close()
+
+	err := NewExecutor(client).ExecuteContext(ctx, command, execOpts)
+	result.End = time.Now()
+	result.Duration = result.End.Sub(result.Start)
+
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+		result.Signal = exitErr.Signal()
+		result.Err = err
+	default:
+		result.Err = err
+	}
+	return result
+}
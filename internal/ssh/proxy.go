@@ -0,0 +1,169 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"goSSH/internal/secret"
+	"goSSH/models"
+	"golang.org/x/net/proxy"
+)
+
+// ProxyNone 表示不使用代理（用于覆盖全局代理设置）
+const ProxyNone = "none"
+
+// ParseProxy 解析并校验代理地址，支持 socks5://、socks5h:// 和 http://
+func ParseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址格式错误: %v", err)
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("不支持的代理类型 '%s'，可用: socks5、socks5h、http", u.Scheme)
+	}
+
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("代理地址必须包含主机和端口，如 %s://proxy.example.com:1080", u.Scheme)
+	}
+	return u, nil
+}
+
+// dialDirect 建立到 address 的TCP连接，配置了代理时通过代理连接
+func dialDirect(ctx context.Context, server *models.Server, address string, timeout time.Duration) (net.Conn, error) {
+	if server.Proxy == "" || server.Proxy == ProxyNone {
+		dialer := &net.Dialer{Timeout: timeout}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	u, err := ParseProxy(server.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password, err := proxyCredentials(server, u)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if u.Scheme == "http" {
+		conn, err = dialHTTPConnect(ctx, u.Host, address, username, password, timeout)
+	} else {
+		conn, err = dialSOCKS5(ctx, u.Host, address, username, password, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("通过代理 %s 连接失败: %w", u.Redacted(), err)
+	}
+	return conn, nil
+}
+
+// proxyCredentials 获取代理认证凭据
+// 密码依次取自：代理地址中的密码 -> 代理密码引用 -> 代理密码
+func proxyCredentials(server *models.Server, u *url.URL) (string, string, error) {
+	if u.User == nil {
+		return "", "", nil
+	}
+
+	username := u.User.Username()
+	if password, ok := u.User.Password(); ok {
+		return username, password, nil
+	}
+
+	if server.ProxyPasswordRef != "" {
+		password, err := secret.Resolve(server.ProxyPasswordRef)
+		if err != nil {
+			return "", "", err
+		}
+		return username, password, nil
+	}
+	return username, server.ProxyPassword, nil
+}
+
+// dialSOCKS5 通过 SOCKS5 代理建立连接
+func dialSOCKS5(ctx context.Context, proxyAddress, address, username, password string, timeout time.Duration) (net.Conn, error) {
+	var auth *proxy.Auth
+	if username != "" {
+		auth = &proxy.Auth{User: username, Password: password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", proxyAddress, auth, &net.Dialer{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+}
+
+// dialHTTPConnect 通过 HTTP 代理的 CONNECT 方法建立隧道
+func dialHTTPConnect(ctx context.Context, proxyAddress, address, username, password string, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// 握手期间设置超时，完成后清除
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		if resp.StatusCode == http.StatusProxyAuthRequired {
+			return nil, fmt.Errorf("代理认证失败: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("代理拒绝连接: %s", strings.TrimSpace(resp.Status))
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	// 代理可能已经转发了服务器的部分数据（如SSH版本标识），需要先读出缓冲区中的内容
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn 优先从缓冲区读取数据的连接
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
@@ -0,0 +1,141 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrInterrupted 表示命令因本地收到中断信号而未执行或被强制结束
+var ErrInterrupted = errors.New("已中断")
+
+// forwardedSignals 转发给远程命令的本地信号
+var forwardedSignals = map[os.Signal]ssh.Signal{
+	os.Interrupt:    ssh.SIGINT,
+	syscall.SIGTERM: ssh.SIGTERM,
+	syscall.SIGHUP:  ssh.SIGHUP,
+}
+
+// SignalRelay 将本地收到的 SIGINT、SIGTERM、SIGHUP 信号转发给正在执行的远程命令
+// 第二次按 Ctrl+C 时不再转发，而是直接关闭所有会话并取消正在建立的连接；收到任何信号后不再开始执行新的命令
+type SignalRelay struct {
+	mu         sync.Mutex
+	sessions   map[*ssh.Session]struct{}
+	cancels    map[int]context.CancelCauseFunc // 正在连接的服务器的取消函数
+	nextCancel int
+	signaled   bool // 已收到信号
+	forced     bool // 已强制关闭会话
+	interrupts int  // 收到 SIGINT 的次数
+	ch         chan os.Signal
+	done       chan struct{}
+}
+
+// NewSignalRelay 开始接管本地的 SIGINT、SIGTERM 和 SIGHUP 信号，使用完毕后需要调用 Stop 恢复默认处理
+func NewSignalRelay() *SignalRelay {
+	r := &SignalRelay{
+		sessions: make(map[*ssh.Session]struct{}),
+		cancels:  make(map[int]context.CancelCauseFunc),
+		ch:       make(chan os.Signal, 4),
+		done:     make(chan struct{}),
+	}
+	signal.Notify(r.ch, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go r.run()
+	return r
+}
+
+// Stop 停止接管信号
+func (r *SignalRelay) Stop() {
+	signal.Stop(r.ch)
+	close(r.done)
+}
+
+// run 处理收到的信号，直到调用 Stop
+func (r *SignalRelay) run() {
+	for {
+		select {
+		case sig := <-r.ch:
+			r.forward(sig)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// forward 将信号转发给所有会话，第二次收到 SIGINT 时关闭所有会话并取消正在建立的连接
+func (r *SignalRelay) forward(sig os.Signal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.signaled = true
+	if sig == os.Interrupt {
+		r.interrupts++
+		if r.interrupts > 1 {
+			r.forced = true
+			for session := range r.sessions {
+				session.Close()
+			}
+			for _, cancel := range r.cancels {
+				cancel(ErrInterrupted)
+			}
+			return
+		}
+	}
+
+	name := forwardedSignals[sig]
+	if sig == os.Interrupt {
+		switch {
+		case len(r.sessions) > 0:
+			fmt.Fprintf(os.Stderr, "\n已向远程命令发送 %s 信号，再次按 Ctrl+C 强制断开\n", name)
+		case len(r.cancels) > 0:
+			fmt.Fprintln(os.Stderr, "\n连接完成后不再执行命令，再次按 Ctrl+C 取消连接")
+		}
+	}
+	for session := range r.sessions {
+		session.Signal(name)
+	}
+}
+
+// watch 登记正在连接的服务器，强制中断时以 ErrInterrupted 调用 cancel；已收到信号时立即调用
+// 返回的函数用于取消登记
+func (r *SignalRelay) watch(cancel context.CancelCauseFunc) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.signaled {
+		cancel(ErrInterrupted)
+		return func() {}
+	}
+
+	id := r.nextCancel
+	r.nextCancel++
+	r.cancels[id] = cancel
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.cancels, id)
+	}
+}
+
+// add 登记正在执行的会话，已收到信号时返回 false，调用方不应再继续执行
+func (r *SignalRelay) add(session *ssh.Session) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.signaled {
+		return false
+	}
+	r.sessions[session] = struct{}{}
+	return true
+}
+
+// remove 取消登记会话，返回会话是否被强制关闭
+func (r *SignalRelay) remove(session *ssh.Session) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, session)
+	return r.forced
+}
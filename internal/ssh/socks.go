@@ -0,0 +1,223 @@
+package ssh
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// SOCKS5 协议常量（RFC 1928、RFC 1929）
+const (
+	socksVersion     = 0x05
+	socksAuthVersion = 0x01
+
+	socksMethodNoAuth       = 0x00
+	socksMethodPassword     = 0x02
+	socksMethodNoAcceptable = 0xff
+
+	socksCmdConnect = 0x01
+
+	socksAtypIPv4   = 0x01
+	socksAtypDomain = 0x03
+	socksAtypIPv6   = 0x04
+
+	socksReplySucceeded          = 0x00
+	socksReplyHostUnreachable    = 0x04
+	socksReplyCommandUnsupported = 0x07
+	socksReplyAtypUnsupported    = 0x08
+)
+
+// SocksAuth 表示 SOCKS5 监听端的用户名/密码认证
+type SocksAuth struct {
+	Username string
+	Password string
+}
+
+// ParseSocksSpec 解析动态转发的监听规则，格式为 [bind_address:]port
+// 未指定监听地址时使用 defaultBind
+func ParseSocksSpec(spec, defaultBind string) (ForwardSpec, error) {
+	parts, err := splitForwardSpec(spec)
+	if err != nil {
+		return ForwardSpec{}, err
+	}
+
+	result := ForwardSpec{BindAddress: defaultBind}
+	switch len(parts) {
+	case 1:
+	case 2:
+		result.BindAddress = parts[0]
+		parts = parts[1:]
+	default:
+		return ForwardSpec{}, fmt.Errorf("监听规则 '%s' 格式错误，应为 [bind_address:]port", spec)
+	}
+
+	if result.BindPort, err = parsePort(parts[0], true); err != nil {
+		return ForwardSpec{}, fmt.Errorf("监听规则 '%s' 的端口错误: %v", spec, err)
+	}
+	return result, nil
+}
+
+// ForwardSOCKS 创建动态端口转发：在本地运行 SOCKS5 代理，通过SSH连接访问客户端请求的目标地址
+// auth 不为 nil 时要求客户端使用用户名/密码认证
+func ForwardSOCKS(client *Client, spec ForwardSpec, auth *SocksAuth) (*Forwarder, error) {
+	if !client.IsConnected() {
+		if err := client.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", spec.ListenAddress())
+	if err != nil {
+		return nil, fmt.Errorf("监听本地地址 %s 失败: %v", spec.ListenAddress(), err)
+	}
+
+	conn := client.GetConnection()
+	return newForwarder(spec, listener, func(local net.Conn) (net.Conn, string, error) {
+		// 握手阶段设置超时，避免客户端不发送请求时一直占用连接
+		local.SetDeadline(time.Now().Add(30 * time.Second))
+		target, err := socksHandshake(local, auth)
+		if err != nil {
+			return nil, "(SOCKS5)", err
+		}
+
+		// 目标主机名由服务器端解析
+		remote, err := conn.Dial("tcp", target)
+		if err != nil {
+			writeSocksReply(local, socksReplyHostUnreachable)
+			return nil, target, err
+		}
+
+		if err := writeSocksReply(local, socksReplySucceeded); err != nil {
+			remote.Close()
+			return nil, target, err
+		}
+		local.SetDeadline(time.Time{})
+		return remote, target, nil
+	}), nil
+}
+
+// socksHandshake 完成 SOCKS5 协商和认证，读取 CONNECT 请求并返回目标地址
+func socksHandshake(conn net.Conn, auth *SocksAuth) (string, error) {
+	// 协商认证方式: VER NMETHODS METHODS
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+	}
+	if header[0] != socksVersion {
+		return "", fmt.Errorf("不支持的 SOCKS 版本: %d", header[0])
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+	}
+
+	method := byte(socksMethodNoAuth)
+	if auth != nil {
+		method = socksMethodPassword
+	}
+	if !slices.Contains(methods, method) {
+		conn.Write([]byte{socksVersion, socksMethodNoAcceptable})
+		return "", errors.New("客户端不支持所需的认证方式")
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return "", err
+	}
+
+	if auth != nil {
+		if err := socksAuthenticate(conn, auth); err != nil {
+			return "", err
+		}
+	}
+
+	// 读取请求: VER CMD RSV ATYP DST.ADDR DST.PORT
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+	}
+	if request[1] != socksCmdConnect {
+		writeSocksReply(conn, socksReplyCommandUnsupported)
+		return "", fmt.Errorf("不支持的 SOCKS 命令: %d（仅支持 CONNECT）", request[1])
+	}
+
+	var host string
+	switch request[3] {
+	case socksAtypIPv4, socksAtypIPv6:
+		size := net.IPv4len
+		if request[3] == socksAtypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+		}
+		host = net.IP(ip).String()
+	case socksAtypDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+		}
+		host = string(domain)
+	default:
+		writeSocksReply(conn, socksReplyAtypUnsupported)
+		return "", fmt.Errorf("不支持的地址类型: %d", request[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", fmt.Errorf("读取 SOCKS 请求失败: %v", err)
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// socksAuthenticate 完成用户名/密码认证: VER ULEN UNAME PLEN PASSWD
+func socksAuthenticate(conn net.Conn, auth *SocksAuth) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("读取 SOCKS 认证信息失败: %v", err)
+	}
+	if header[0] != socksAuthVersion {
+		return fmt.Errorf("不支持的 SOCKS 认证版本: %d", header[0])
+	}
+
+	username := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, username); err != nil {
+		return fmt.Errorf("读取 SOCKS 认证信息失败: %v", err)
+	}
+
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return fmt.Errorf("读取 SOCKS 认证信息失败: %v", err)
+	}
+	password := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return fmt.Errorf("读取 SOCKS 认证信息失败: %v", err)
+	}
+
+	userOK := subtle.ConstantTimeCompare(username, []byte(auth.Username)) == 1
+	passOK := subtle.ConstantTimeCompare(password, []byte(auth.Password)) == 1
+	if !userOK || !passOK {
+		conn.Write([]byte{socksAuthVersion, 0x01})
+		return fmt.Errorf("SOCKS 认证失败（用户名: %s）", username)
+	}
+
+	_, err := conn.Write([]byte{socksAuthVersion, 0x00})
+	return err
+}
+
+// writeSocksReply 发送 SOCKS5 应答，绑定地址固定为 0.0.0.0:0
+func writeSocksReply(conn net.Conn, reply byte) error {
+	_, err := conn.Write([]byte{socksVersion, reply, 0x00, socksAtypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
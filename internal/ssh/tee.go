@@ -0,0 +1,159 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// teeBufferLimit 最快的读取者最多领先已开始读取的最慢读取者的字节数
+	teeBufferLimit = 4 << 20
+	// teePendingLimit 为还没开始读取的读取者最多保留的字节数
+	teePendingLimit = 64 << 20
+)
+
+// errTeeDropped 表示读取者等待开始期间输入超过了 teePendingLimit，已无法读到完整的数据
+var errTeeDropped = fmt.Errorf("等待执行期间标准输入超过 %dMB，无法保留完整的输入，请增大并发数", teePendingLimit>>20)
+
+// stdinTee 将一个输入源的数据分发给多个读取者，每个读取者都从头读取完整的数据
+// 数据在所有读取者读过（或关闭）之后才会释放；已开始读取的读取者之间按 teeBufferLimit 控制读取进度，
+// 还没开始读取的读取者（如等待并发名额的服务器）不会阻止其他读取者，为它们保留的数据超过 teePendingLimit 时将其丢弃
+type stdinTee struct {
+	src     io.Reader
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     []byte // 从 base 开始、尚未被所有读取者读过的数据
+	base    int64  // buf 第一个字节在输入中的位置
+	err     error  // 读取输入源结束时的错误（正常结束为 io.EOF）
+	reading bool   // 是否有读取者正在从输入源读取
+	readers []*teeReader
+}
+
+// teeReader 是 stdinTee 的一个读取者
+type teeReader struct {
+	tee     *stdinTee
+	off     int64 // 下一个要读取的字节在输入中的位置
+	started bool
+	closed  bool
+	err     error // 被丢弃时为 errTeeDropped
+}
+
+// newStdinTee 创建 n 个读取同一输入源的读取者
+func newStdinTee(src io.Reader, n int) []*teeReader {
+	t := &stdinTee{src: src}
+	t.cond = sync.NewCond(&t.mu)
+	t.readers = make([]*teeReader, n)
+	for i := range t.readers {
+		t.readers[i] = &teeReader{tee: t}
+	}
+	return t.readers
+}
+
+// Read 读取数据，其他读取者已经读过的数据直接从缓冲区返回，否则从输入源读取
+func (r *teeReader) Read(p []byte) (int, error) {
+	t := r.tee
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r.started = true
+	for {
+		if r.closed {
+			if r.err != nil {
+				return 0, r.err
+			}
+			return 0, io.ErrClosedPipe
+		}
+
+		end := t.base + int64(len(t.buf))
+		if r.off < end {
+			n := copy(p, t.buf[r.off-t.base:])
+			r.off += int64(n)
+			t.release()
+			return n, nil
+		}
+		if t.err != nil {
+			return 0, t.err
+		}
+
+		// 同一时间只有一个读取者从输入源读取，领先最慢的读取者太多时等待
+		if !t.reading && end-t.slowest() < teeBufferLimit {
+			t.reading = true
+			chunk := make([]byte, 32*1024)
+			t.mu.Unlock()
+			n, err := t.src.Read(chunk)
+			t.mu.Lock()
+			t.reading = false
+			t.buf = append(t.buf, chunk[:n]...)
+			if err != nil {
+				t.err = err
+			}
+			t.dropPending()
+			t.release()
+			continue
+		}
+		t.cond.Wait()
+	}
+}
+
+// start 标记读取者开始读取，之后不会再被丢弃；已被丢弃时返回 errTeeDropped
+// 在确定要使用读取者（如开始连接服务器）时调用，避免连接期间被丢弃
+func (r *teeReader) start() error {
+	t := r.tee
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	r.started = true
+	return nil
+}
+
+// Close 关闭读取者，不再为其保留数据
+func (r *teeReader) Close() error {
+	t := r.tee
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r.closed = true
+	t.release()
+	return nil
+}
+
+// slowest 返回已开始读取且未关闭的读取者中最小的读取位置
+func (t *stdinTee) slowest() int64 {
+	end := t.base + int64(len(t.buf))
+	for _, r := range t.readers {
+		if r.started && !r.closed {
+			end = min(end, r.off)
+		}
+	}
+	return end
+}
+
+// dropPending 丢弃为其保留的数据超过 teePendingLimit 且还没开始读取的读取者
+func (t *stdinTee) dropPending() {
+	end := t.base + int64(len(t.buf))
+	for _, r := range t.readers {
+		if !r.started && !r.closed && end-r.off > teePendingLimit {
+			r.closed = true
+			r.err = errTeeDropped
+		}
+	}
+}
+
+// release 释放所有未关闭的读取者都已读过的数据，并唤醒等待的读取者
+func (t *stdinTee) release() {
+	end := t.base + int64(len(t.buf))
+	for _, r := range t.readers {
+		if !r.closed {
+			end = min(end, r.off)
+		}
+	}
+	if n := end - t.base; n > 0 {
+		t.buf = t.buf[n:]
+		t.base = end
+	}
+	t.cond.Broadcast()
+}
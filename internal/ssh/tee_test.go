@@ -0,0 +1,250 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// teeChunk 是 stdinTee 每次从输入源读取的字节数
+const teeChunk = 32 * 1024
+
+// teeReadAll 以 chunk 字节为单位读取全部数据，每次读取后等待 delay
+// 同时记录读取过程中缓冲区的最大长度
+func teeReadAll(r *teeReader, chunk int, delay time.Duration, maxBuffered *int) ([]byte, error) {
+	var out bytes.Buffer
+	p := make([]byte, chunk)
+	for {
+		n, err := r.Read(p)
+		out.Write(p[:n])
+
+		r.tee.mu.Lock()
+		*maxBuffered = max(*maxBuffered, len(r.tee.buf))
+		r.tee.mu.Unlock()
+
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return out.Bytes(), err
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+	}
+}
+
+func TestStdinTeeReadersAtDifferentSpeeds(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		chunks []int           // 每个读取者每次读取的字节数
+		delays []time.Duration // 每个读取者每次读取后的等待时间
+	}{
+		{"单个读取者", 1 << 20, []int{4096}, []time.Duration{0}},
+		{"空输入", 0, []int{1024, 1024}, []time.Duration{0, 0}},
+		{"读取大小不同", 3 << 20, []int{7, 4096, 1 << 20}, []time.Duration{0, 0, 0}},
+		{"一个读取者很慢", 2 << 20, []int{64 * 1024, 64 * 1024}, []time.Duration{0, time.Millisecond}},
+		{"超过缓冲上限", 3 * teeBufferLimit, []int{256 * 1024, 8 * 1024, 128 * 1024}, []time.Duration{0, 0, 100 * time.Microsecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, tt.size)
+			rand.New(rand.NewSource(1)).Read(data)
+
+			readers := newStdinTee(bytes.NewReader(data), len(tt.chunks))
+			// 先以空的读取标记所有读取者都已开始，使缓冲区从一开始就受上限控制
+			for _, r := range readers {
+				r.Read(nil)
+			}
+
+			var wg sync.WaitGroup
+			results := make([][]byte, len(readers))
+			errs := make([]error, len(readers))
+			maxBuffered := make([]int, len(readers))
+			for i, r := range readers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					results[i], errs[i] = teeReadAll(r, tt.chunks[i], tt.delays[i], &maxBuffered[i])
+				}()
+			}
+			wg.Wait()
+
+			for i := range readers {
+				if errs[i] != nil {
+					t.Fatalf("读取者 %d 出错: %v", i, errs[i])
+				}
+				if !bytes.Equal(results[i], data) {
+					t.Fatalf("读取者 %d 读到 %d 字节，与输入（%d 字节）不一致", i, len(results[i]), len(data))
+				}
+				// 缓冲区最多比上限多一次从输入源读取的数据
+				if maxBuffered[i] > teeBufferLimit+teeChunk {
+					t.Errorf("读取者 %d 读取期间缓冲区达到 %d 字节，超过上限 %d", i, maxBuffered[i], teeBufferLimit+teeChunk)
+				}
+			}
+		})
+	}
+}
+
+func TestStdinTeeClose(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{
+			// 落后的读取者读过一次后不再读取，领先的读取者达到缓冲上限后等待；关闭落后的读取者后继续读完
+			name: "关闭落后的读取者",
+			run: func(t *testing.T) {
+				data := make([]byte, 2*teeBufferLimit)
+				rand.New(rand.NewSource(2)).Read(data)
+				readers := newStdinTee(bytes.NewReader(data), 2)
+
+				if _, err := readers[1].Read(make([]byte, 1)); err != nil {
+					t.Fatalf("读取失败: %v", err)
+				}
+
+				done := make(chan []byte)
+				go func() {
+					out, _ := io.ReadAll(readers[0])
+					done <- out
+				}()
+
+				select {
+				case <-done:
+					t.Fatal("落后的读取者未关闭时，领先的读取者不应读完全部数据")
+				case <-time.After(100 * time.Millisecond):
+				}
+
+				readers[1].Close()
+				select {
+				case out := <-done:
+					if !bytes.Equal(out, data) {
+						t.Fatalf("读到 %d 字节，与输入（%d 字节）不一致", len(out), len(data))
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("关闭落后的读取者后，领先的读取者仍在等待")
+				}
+			},
+		},
+		{
+			// 一个读取者正在从输入源读取时，其他读取者等待；关闭等待中的读取者后其 Read 立即返回
+			name: "关闭等待中的读取者",
+			run: func(t *testing.T) {
+				src, w := io.Pipe()
+				readers := newStdinTee(src, 2)
+
+				first := make(chan error, 1)
+				go func() {
+					_, err := readers[0].Read(make([]byte, 16))
+					first <- err
+				}()
+				waitReading(t, readers[0].tee)
+
+				second := make(chan error, 1)
+				go func() {
+					_, err := readers[1].Read(make([]byte, 16))
+					second <- err
+				}()
+				time.Sleep(20 * time.Millisecond)
+
+				readers[1].Close()
+				select {
+				case err := <-second:
+					if !errors.Is(err, io.ErrClosedPipe) {
+						t.Fatalf("关闭后 Read 返回 %v，应为 io.ErrClosedPipe", err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("关闭后 Read 仍在等待")
+				}
+
+				w.Write([]byte("hello"))
+				if err := <-first; err != nil {
+					t.Fatalf("读取失败: %v", err)
+				}
+				w.Close()
+			},
+		},
+		{
+			// 关闭后再读取直接返回错误
+			name: "关闭后读取",
+			run: func(t *testing.T) {
+				readers := newStdinTee(bytes.NewReader([]byte("data")), 1)
+				readers[0].Close()
+				if _, err := readers[0].Read(make([]byte, 4)); !errors.Is(err, io.ErrClosedPipe) {
+					t.Fatalf("关闭后 Read 返回 %v，应为 io.ErrClosedPipe", err)
+				}
+			},
+		},
+		{
+			// 尚未开始读取的读取者关闭后，其他读取者读过的数据随即释放
+			name: "关闭未开始读取的读取者",
+			run: func(t *testing.T) {
+				data := bytes.Repeat([]byte("x"), 3*teeChunk)
+				readers := newStdinTee(bytes.NewReader(data), 2)
+
+				out, err := io.ReadAll(readers[0])
+				if err != nil || !bytes.Equal(out, data) {
+					t.Fatalf("读取失败: %d 字节, %v", len(out), err)
+				}
+				if n := len(readers[0].tee.buf); n != len(data) {
+					t.Fatalf("未开始读取的读取者需要的数据应保留在缓冲区，实际保留 %d 字节", n)
+				}
+
+				readers[1].Close()
+				if n := len(readers[0].tee.buf); n != 0 {
+					t.Fatalf("关闭后缓冲区应为空，实际保留 %d 字节", n)
+				}
+			},
+		},
+		{
+			// 为未开始读取的读取者保留的数据超过上限时丢弃它，已开始的读取者不受影响
+			name: "未开始读取的读取者超过保留上限",
+			run: func(t *testing.T) {
+				data := bytes.Repeat([]byte("x"), teePendingLimit+teeChunk)
+				readers := newStdinTee(bytes.NewReader(data), 3)
+				if err := readers[1].start(); err != nil {
+					t.Fatalf("start 返回 %v", err)
+				}
+
+				go io.Copy(io.Discard, readers[1])
+				out, err := io.ReadAll(readers[0])
+				if err != nil || !bytes.Equal(out, data) {
+					t.Fatalf("读取失败: %d 字节, %v", len(out), err)
+				}
+
+				if err := readers[2].start(); !errors.Is(err, errTeeDropped) {
+					t.Fatalf("start 返回 %v，应为 errTeeDropped", err)
+				}
+				if _, err := readers[2].Read(make([]byte, 1)); !errors.Is(err, errTeeDropped) {
+					t.Fatalf("Read 返回 %v，应为 errTeeDropped", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+// waitReading 等待有读取者开始从输入源读取
+func waitReading(t *testing.T, tee *stdinTee) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		tee.mu.Lock()
+		reading := tee.reading
+		tee.mu.Unlock()
+		if reading {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("读取者没有开始从输入源读取")
+}
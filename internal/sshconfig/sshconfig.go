@@ -0,0 +1,257 @@
+package sshconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxIncludeDepth Include 的最大嵌套深度（与 OpenSSH 相同）
+const maxIncludeDepth = 16
+
+// Config 表示解析后的 OpenSSH 客户端配置（~/.ssh/config）
+type Config struct {
+	blocks   []*block
+	Warnings []string // 解析过程中忽略的内容（如 Match 段）
+}
+
+// block 表示一个 Host 或 Match 段，文件开头不属于任何段的配置视为 Host *
+type block struct {
+	patterns []string // Host 模式，为 nil 表示 Match 段（不支持，永不匹配）
+	options  []option
+}
+
+// option 表示一条配置项
+type option struct {
+	key  string   // 关键字（小写）
+	args []string // 参数
+}
+
+// Load 读取并解析 OpenSSH 客户端配置文件
+// Include 中的相对路径以 path 所在目录为基准，支持通配符
+func Load(path string) (*Config, error) {
+	config := &Config{}
+	current := &block{patterns: []string{"*"}}
+	config.blocks = append(config.blocks, current)
+
+	dir := filepath.Dir(path)
+	if err := config.parseFile(path, dir, &current, 0); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// parseFile 解析一个配置文件，current 为当前所在的段，Include 文件中第一个 Host 之前的配置属于该段
+func (c *Config) parseFile(path, dir string, current **block, depth int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		key, args, err := parseLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s 第 %d 行: %v", path, lineNo, err)
+		}
+		if key == "" {
+			continue
+		}
+
+		switch key {
+		case "host":
+			if len(args) == 0 {
+				return fmt.Errorf("%s 第 %d 行: Host 缺少主机名", path, lineNo)
+			}
+			*current = &block{patterns: args}
+			c.blocks = append(c.blocks, *current)
+		case "match":
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s 第 %d 行: 不支持 Match，已忽略该段配置", path, lineNo))
+			*current = &block{}
+			c.blocks = append(c.blocks, *current)
+		case "include":
+			if depth >= maxIncludeDepth {
+				return fmt.Errorf("%s 第 %d 行: Include 嵌套层数过多", path, lineNo)
+			}
+			for _, pattern := range args {
+				if err := c.include(pattern, dir, *current, depth+1); err != nil {
+					return fmt.Errorf("%s 第 %d 行: %v", path, lineNo, err)
+				}
+			}
+		default:
+			(*current).options = append((*current).options, option{key: key, args: args})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", path, err)
+	}
+	return nil
+}
+
+// include 解析 Include 指定的文件，不存在的文件会被忽略（与 OpenSSH 相同）
+// 每个文件都从 current 段开始解析，文件中的 Host 段不影响 Include 之后的配置所属的段
+func (c *Config) include(pattern, dir string, current *block, depth int) error {
+	pattern = expandHome(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("Include 路径 '%s' 无效: %v", pattern, err)
+	}
+	for _, match := range matches {
+		if info, err := os.Stat(match); err != nil || info.IsDir() {
+			continue
+		}
+		scope := current
+		if err := c.parseFile(match, dir, &scope, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLine 将一行配置拆分为关键字和参数，空行和注释返回空关键字
+// 支持 "Key Value"、"Key=Value" 两种写法，参数可以用双引号包含空格
+func parseLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil, nil
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	if strings.HasPrefix(rest, "=") {
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+
+	var args []string
+	for rest != "" {
+		if strings.HasPrefix(rest, "#") {
+			break
+		}
+		var arg string
+		if rest[0] == '"' {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return "", nil, fmt.Errorf("引号不匹配")
+			}
+			arg, rest = rest[1:closing+1], rest[closing+2:]
+		} else if i := strings.IndexAny(rest, " \t"); i >= 0 {
+			arg, rest = rest[:i], rest[i:]
+		} else {
+			arg, rest = rest, ""
+		}
+		args = append(args, arg)
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return key, args, nil
+}
+
+// Hosts 返回配置中所有具体的主机别名（不含通配符和否定模式），按出现顺序去重
+func (c *Config) Hosts() []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, b := range c.blocks[1:] {
+		for _, pattern := range b.patterns {
+			if strings.ContainsAny(pattern, "*?!") || seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			hosts = append(hosts, pattern)
+		}
+	}
+	return hosts
+}
+
+// Get 返回适用于主机的配置项参数，与 OpenSSH 相同，按文件顺序第一个匹配的值生效
+// 未配置时返回 nil
+func (c *Config) Get(host, key string) []string {
+	key = strings.ToLower(key)
+	for _, b := range c.blocks {
+		if !b.matches(host) {
+			continue
+		}
+		for _, opt := range b.options {
+			if opt.key == key {
+				return opt.args
+			}
+		}
+	}
+	return nil
+}
+
+// GetString 返回配置项的第一个参数，未配置时返回空字符串
+func (c *Config) GetString(host, key string) string {
+	if args := c.Get(host, key); len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
+
+// matches 判断段是否适用于主机：至少匹配一个模式，且不匹配任何否定模式
+func (b *block) matches(host string) bool {
+	matched := false
+	for _, pattern := range b.patterns {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchPattern(negated, host) {
+				return false
+			}
+		} else if matchPattern(pattern, host) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchPattern 使用 OpenSSH 的通配符规则匹配主机名（* 匹配任意字符，? 匹配单个字符，不区分大小写）
+func matchPattern(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(host); i++ {
+				if matchPattern(pattern, host[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if host == "" {
+				return false
+			}
+		default:
+			if host == "" || host[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, host = pattern[1:], host[1:]
+	}
+	return host == ""
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
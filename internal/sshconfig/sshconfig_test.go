@@ -0,0 +1,191 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFiles 在临时目录中写入配置文件，返回目录路径
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		hosts []string
+		want  map[[2]string]string // {主机, 关键字} -> 第一个参数
+	}{
+		{
+			name: "第一个匹配的值生效",
+			files: map[string]string{"config": `
+User global
+Host web
+  HostName 10.0.0.1
+  Port 2222
+Host web db
+  HostName ignored
+  User admin
+Host *
+  User fallback
+  IdentityFile ~/.ssh/id_ed25519
+`},
+			hosts: []string{"web", "db"},
+			want: map[[2]string]string{
+				{"web", "HostName"}:     "10.0.0.1",
+				{"web", "port"}:         "2222",
+				{"web", "User"}:         "global",
+				{"db", "HostName"}:      "ignored",
+				{"db", "IdentityFile"}:  "~/.ssh/id_ed25519",
+				{"other", "HostName"}:   "",
+				{"other", "User"}:       "global",
+				{"web", "ProxyCommand"}: "",
+			},
+		},
+		{
+			name: "Key=Value、引号和注释",
+			files: map[string]string{"config": `
+Host "quoted"
+  HostName=10.0.0.2 # 注释
+  IdentityFile "/path/with space/id"
+`},
+			hosts: []string{"quoted"},
+			want: map[[2]string]string{
+				{"quoted", "hostname"}:     "10.0.0.2",
+				{"quoted", "identityfile"}: "/path/with space/id",
+			},
+		},
+		{
+			name: "通配符和否定模式",
+			files: map[string]string{"config": `
+Host *.prod !bastion.prod
+  User deploy
+Host web?
+  Port 2200
+`},
+			hosts: nil,
+			want: map[[2]string]string{
+				{"app.prod", "User"}:     "deploy",
+				{"APP.PROD", "User"}:     "deploy",
+				{"bastion.prod", "User"}: "",
+				{"web1", "Port"}:         "2200",
+				{"web10", "Port"}:        "",
+			},
+		},
+		{
+			// Include 文件中第一个 Host 之前的配置属于 Include 所在的段，
+			// 文件中的 Host 段结束后回到 Include 所在的段
+			name: "Include 不影响之后配置所属的段",
+			files: map[string]string{
+				"config": `
+Host bastion
+  Include conf.d/*.conf
+  User outer
+Host db
+  HostName 10.0.0.3
+`,
+				"conf.d/a.conf": `
+  HostName 10.0.0.1
+Host included
+  HostName 10.0.0.9
+`,
+				"conf.d/b.conf": `
+  Port 2222
+`,
+			},
+			hosts: []string{"bastion", "included", "db"},
+			want: map[[2]string]string{
+				{"bastion", "HostName"}: "10.0.0.1",
+				{"bastion", "User"}:     "outer",
+				{"bastion", "Port"}:     "2222",
+				{"included", "User"}:    "",
+				{"included", "Port"}:    "",
+				{"db", "HostName"}:      "10.0.0.3",
+			},
+		},
+		{
+			name: "不存在的 Include 文件被忽略",
+			files: map[string]string{"config": `
+Include missing.conf
+Host web
+  HostName 10.0.0.1
+`},
+			hosts: []string{"web"},
+			want: map[[2]string]string{
+				{"web", "HostName"}: "10.0.0.1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			config, err := Load(filepath.Join(dir, "config"))
+			if err != nil {
+				t.Fatalf("Load 返回错误: %v", err)
+			}
+
+			if hosts := config.Hosts(); !slices.Equal(hosts, tt.hosts) {
+				t.Errorf("Hosts() = %v，应为 %v", hosts, tt.hosts)
+			}
+			for key, want := range tt.want {
+				if got := config.GetString(key[0], key[1]); got != want {
+					t.Errorf("GetString(%q, %q) = %q，应为 %q", key[0], key[1], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"Host 缺少主机名", map[string]string{"config": "Host\n"}},
+		{"引号不匹配", map[string]string{"config": "Host web\n  HostName \"10.0.0.1\n"}},
+		{"Include 循环", map[string]string{"config": "Include config\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			if _, err := Load(filepath.Join(dir, "config")); err == nil {
+				t.Fatal("Load 应返回错误")
+			}
+		})
+	}
+}
+
+func TestMatchWarnings(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config": `
+Match host web
+  User ignored
+Host web
+  User admin
+`})
+	config, err := Load(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("Load 返回错误: %v", err)
+	}
+	if len(config.Warnings) != 1 {
+		t.Errorf("Warnings = %v，应有 1 条", config.Warnings)
+	}
+	if got := config.GetString("web", "User"); got != "admin" {
+		t.Errorf("Match 段应被忽略，User = %q", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	"goSSH/models"
 )
@@ -16,22 +17,70 @@ type Storage struct {
 
 // NewStorage 创建新的存储实例
 func NewStorage() (*Storage, error) {
+	gosshDir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	configPath := filepath.Join(gosshDir, "servers.json")
+	return &Storage{configPath: configPath}, nil
+}
+
+// Dir 返回 gossh 配置目录（不存在时自动创建）
+// 除 servers.json 外，known_hosts 等其他数据文件也存放在该目录下
+func Dir() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("获取配置目录失败: %v", err)
+		return "", fmt.Errorf("获取配置目录失败: %v", err)
 	}
 
+	// 配置目录中有后台进程的套接字，只允许当前用户访问
 	gosshDir := filepath.Join(configDir, "gossh")
-	if err := os.MkdirAll(gosshDir, 0755); err != nil {
-		return nil, fmt.Errorf("创建配置目录失败: %v", err)
+	if err := os.MkdirAll(gosshDir, 0700); err != nil {
+		return "", fmt.Errorf("创建配置目录失败: %v", err)
 	}
 
-	configPath := filepath.Join(gosshDir, "servers.json")
-	return &Storage{configPath: configPath}, nil
+	// 旧版本创建的目录权限为 0755，收紧为 0700
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(gosshDir); err == nil && info.Mode().Perm()&0077 != 0 {
+			if err := os.Chmod(gosshDir, 0700); err != nil {
+				return "", fmt.Errorf("设置配置目录权限失败: %v", err)
+			}
+		}
+	}
+
+	return gosshDir, nil
 }
 
 // Load 加载配置文件
+// 启用主密码模式时会解密敏感字段，返回的配置中均为明文
 func (s *Storage) Load() (*models.ServerConfig, error) {
+	config, err := s.loadRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Vault == nil {
+		return config, nil
+	}
+
+	plaintext, err := decryptConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 自动加密启用主密码模式前遗留或手动添加的明文字段（包括代理地址中的密码）
+	if moveProxyPasswords(config) || plaintext {
+		if err := s.Save(config); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// loadRaw 加载配置文件原始内容（不解密）
+func (s *Storage) loadRaw() (*models.ServerConfig, error) {
 	config := &models.ServerConfig{
 		Servers: make([]models.Server, 0),
 	}
@@ -58,16 +107,30 @@ func (s *Storage) Load() (*models.ServerConfig, error) {
 }
 
 // Save 保存配置文件
+// 代理地址中的密码会移到 ProxyPassword 字段，启用主密码模式时，敏感字段会在写入前加密
 func (s *Storage) Save(config *models.ServerConfig) error {
+	moveProxyPasswords(config)
+	if config.Vault != nil {
+		encrypted, err := encryptConfig(config)
+		if err != nil {
+			return err
+		}
+		config = encrypted
+	}
+
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %v", err)
 	}
 
-	if err := os.WriteFile(s.configPath, data, 0644); err != nil {
+	if err := os.WriteFile(s.configPath, data, 0600); err != nil {
 		return fmt.Errorf("写入配置文件失败: %v", err)
 	}
 
+	// 配置文件包含认证信息，修正旧版本创建的文件权限
+	if err := os.Chmod(s.configPath, 0600); err != nil {
+		return fmt.Errorf("设置配置文件权限失败: %v", err)
+	}
+
 	return nil
 }
-
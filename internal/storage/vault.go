@@ -0,0 +1,420 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/manifoldco/promptui"
+	"github.com/mattn/go-isatty"
+	"goSSH/models"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptedPrefix   = "enc:v1:"              // 加密字段的前缀
+	vaultCheckText    = "gossh-vault-check"    // 用于验证主密码的明文
+	masterPasswordEnv = "GOSS_MASTER_PASSWORD" // 非交互环境下提供主密码的环境变量
+	vaultKeyLength    = 32                     // AES-256 密钥长度
+)
+
+// ErrVaultLocked 表示配置文件已加密，但无法获取主密码
+var ErrVaultLocked = errors.New("配置文件已加密，请先执行 'goss vault unlock' 或设置 " + masterPasswordEnv + " 环境变量")
+
+// ErrWrongMasterPassword 表示主密码错误
+var ErrWrongMasterPassword = errors.New("主密码错误")
+
+// 已解锁的密钥缓存（按盐值区分），保证同一进程内只需解锁一次
+var (
+	keyCache   = make(map[string][]byte)
+	keyCacheMu sync.Mutex
+)
+
+// secretFields 返回服务器配置中需要加密存储的字段
+func secretFields(server *models.Server) []*string {
+	return []*string{&server.Password, &server.Passphrase, &server.ProxyPassword, &server.TOTPSecret}
+}
+
+// moveProxyPasswords 将服务器代理地址中的密码移到 ProxyPassword 字段，使其与其他密码一样加密存储
+// 代理地址中的密码优先于代理密码引用，因此同时清除引用；返回是否有修改
+func moveProxyPasswords(config *models.ServerConfig) bool {
+	moved := false
+	for i := range config.Servers {
+		server := &config.Servers[i]
+		u, err := url.Parse(server.Proxy)
+		if err != nil || u.User == nil {
+			continue
+		}
+		password, ok := u.User.Password()
+		if !ok {
+			continue
+		}
+
+		u.User = url.User(u.User.Username())
+		server.Proxy = u.String()
+		server.ProxyPassword = password
+		server.ProxyPasswordRef = ""
+		moved = true
+	}
+	return moved
+}
+
+// RedactSecrets 清除服务器配置中需要加密存储的敏感字段，用于导出等场景
+func RedactSecrets(server *models.Server) {
+	for _, field := range secretFields(server) {
+		*field = ""
+	}
+}
+
+// newVault 生成新的加密参数
+func newVault() (*models.Vault, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("生成随机盐值失败: %v", err)
+	}
+
+	return &models.Vault{
+		KDF:  "scrypt",
+		Salt: base64.StdEncoding.EncodeToString(salt),
+		N:    1 << 15,
+		R:    8,
+		P:    1,
+	}, nil
+}
+
+// deriveKey 使用主密码派生加密密钥
+func deriveKey(password string, vault *models.Vault) ([]byte, error) {
+	if vault.KDF != "scrypt" {
+		return nil, fmt.Errorf("不支持的密钥派生算法: %s", vault.KDF)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(vault.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("解析盐值失败: %v", err)
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, vault.N, vault.R, vault.P, vaultKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %v", err)
+	}
+	return key, nil
+}
+
+// encryptValue 使用 AES-GCM 加密字符串
+func encryptValue(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %v", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue 解密由 encryptValue 加密的字符串
+func decryptValue(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("解析加密数据失败: %v", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("加密数据格式错误")
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败: %v", err)
+	}
+	return string(plain), nil
+}
+
+// newGCM 创建 AES-GCM 加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// isEncrypted 判断字段值是否已加密
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// verifyKey 验证密钥是否与加密参数匹配
+func verifyKey(vault *models.Vault, key []byte) error {
+	plain, err := decryptValue(key, vault.Check)
+	if err != nil || plain != vaultCheckText {
+		return ErrWrongMasterPassword
+	}
+	return nil
+}
+
+// cachedKey 返回缓存中的密钥
+func cachedKey(vault *models.Vault) []byte {
+	keyCacheMu.Lock()
+	defer keyCacheMu.Unlock()
+	return keyCache[vault.Salt]
+}
+
+// cacheKey 缓存已解锁的密钥
+func cacheKey(vault *models.Vault, key []byte) {
+	keyCacheMu.Lock()
+	defer keyCacheMu.Unlock()
+	keyCache[vault.Salt] = key
+}
+
+// vaultKey 获取用于解密配置的密钥
+// 依次尝试：进程内缓存 -> 后台解锁进程 -> 环境变量 -> 交互式输入主密码
+func vaultKey(vault *models.Vault) ([]byte, error) {
+	if key := cachedKey(vault); key != nil {
+		return key, nil
+	}
+
+	if key, err := agentKey(); err == nil && verifyKey(vault, key) == nil {
+		cacheKey(vault, key)
+		return key, nil
+	}
+
+	password := os.Getenv(masterPasswordEnv)
+	if password == "" {
+		if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+			return nil, ErrVaultLocked
+		}
+
+		var err error
+		password, err = PromptMasterPassword("主密码")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return UnlockKey(vault, password)
+}
+
+// UnlockKey 使用主密码解锁并返回加密密钥
+func UnlockKey(vault *models.Vault, password string) ([]byte, error) {
+	key, err := deriveKey(password, vault)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyKey(vault, key); err != nil {
+		return nil, err
+	}
+
+	cacheKey(vault, key)
+	return key, nil
+}
+
+// PromptMasterPassword 交互式输入主密码
+func PromptMasterPassword(label string) (string, error) {
+	prompt := promptui.Prompt{
+		Label:  label,
+		Mask:   '*',
+		Stdout: os.Stderr,
+		Validate: func(input string) error {
+			if input == "" {
+				return fmt.Errorf("主密码不能为空")
+			}
+			return nil
+		},
+	}
+
+	password, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("输入主密码取消: %v", err)
+	}
+	return password, nil
+}
+
+// decryptConfig 解密配置中的敏感字段
+// 返回值表示是否存在尚未加密的明文字段（需要迁移）
+func decryptConfig(config *models.ServerConfig) (bool, error) {
+	key, err := vaultKey(config.Vault)
+	if err != nil {
+		return false, err
+	}
+
+	plaintext := false
+	for i := range config.Servers {
+		for _, field := range secretFields(&config.Servers[i]) {
+			if *field == "" {
+				continue
+			}
+			if !isEncrypted(*field) {
+				plaintext = true
+				continue
+			}
+
+			plain, err := decryptValue(key, *field)
+			if err != nil {
+				return false, fmt.Errorf("解密服务器 '%s' 的配置失败: %v", config.Servers[i].Name, err)
+			}
+			*field = plain
+		}
+	}
+
+	return plaintext, nil
+}
+
+// encryptConfig 返回敏感字段已加密的配置副本，不修改原配置
+func encryptConfig(config *models.ServerConfig) (*models.ServerConfig, error) {
+	key, err := vaultKey(config.Vault)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := *config
+	encrypted.Servers = slices.Clone(config.Servers)
+	for i := range encrypted.Servers {
+		for _, field := range secretFields(&encrypted.Servers[i]) {
+			if *field == "" || isEncrypted(*field) {
+				continue
+			}
+
+			value, err := encryptValue(key, *field)
+			if err != nil {
+				return nil, err
+			}
+			*field = value
+		}
+	}
+
+	return &encrypted, nil
+}
+
+// VaultEnabled 判断是否已启用主密码模式
+func (s *Storage) VaultEnabled() (bool, error) {
+	config, err := s.loadRaw()
+	if err != nil {
+		return false, err
+	}
+	return config.Vault != nil, nil
+}
+
+// Vault 返回当前的加密参数，未启用主密码模式时返回 nil
+func (s *Storage) Vault() (*models.Vault, error) {
+	config, err := s.loadRaw()
+	if err != nil {
+		return nil, err
+	}
+	return config.Vault, nil
+}
+
+// UnlockedKey 返回解锁后的加密密钥（必要时询问主密码），用于传递给后台进程
+// 未启用主密码模式时返回 nil
+func (s *Storage) UnlockedKey() ([]byte, error) {
+	config, err := s.loadRaw()
+	if err != nil {
+		return nil, err
+	}
+	if config.Vault == nil {
+		return nil, nil
+	}
+	return vaultKey(config.Vault)
+}
+
+// UseKey 使用由其他进程传入的加密密钥，避免后台进程再次询问主密码
+func (s *Storage) UseKey(key []byte) error {
+	config, err := s.loadRaw()
+	if err != nil {
+		return err
+	}
+	if config.Vault == nil {
+		return nil
+	}
+	if err := verifyKey(config.Vault, key); err != nil {
+		return err
+	}
+
+	cacheKey(config.Vault, key)
+	return nil
+}
+
+// InitVault 启用主密码模式，并加密所有已有的明文密码
+// 返回被加密的字段数量
+func (s *Storage) InitVault(password string) (int, error) {
+	config, err := s.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	if config.Vault != nil {
+		return 0, fmt.Errorf("已启用主密码模式")
+	}
+
+	if err := s.setMasterPassword(config, password); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range config.Servers {
+		for _, field := range secretFields(&config.Servers[i]) {
+			if *field != "" {
+				count++
+			}
+		}
+	}
+
+	return count, s.Save(config)
+}
+
+// Rekey 更换主密码，使用新密钥重新加密所有敏感字段
+func (s *Storage) Rekey(newPassword string) error {
+	config, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if config.Vault == nil {
+		return fmt.Errorf("未启用主密码模式，请先执行 'goss vault init'")
+	}
+
+	if err := s.setMasterPassword(config, newPassword); err != nil {
+		return err
+	}
+	return s.Save(config)
+}
+
+// setMasterPassword 为配置生成新的加密参数，并缓存新密钥
+func (s *Storage) setMasterPassword(config *models.ServerConfig, password string) error {
+	vault, err := newVault()
+	if err != nil {
+		return err
+	}
+
+	key, err := deriveKey(password, vault)
+	if err != nil {
+		return err
+	}
+
+	vault.Check, err = encryptValue(key, vaultCheckText)
+	if err != nil {
+		return err
+	}
+
+	cacheKey(vault, key)
+	config.Vault = vault
+	return nil
+}
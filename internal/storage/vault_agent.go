@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"goSSH/internal/daemon"
+)
+
+// 后台解锁进程支持的请求
+const (
+	agentCmdGet    = "get"    // 获取密钥
+	agentCmdLock   = "lock"   // 清除密钥并退出
+	agentCmdStatus = "status" // 查询剩余有效时间
+)
+
+// VaultSocketPath 返回后台解锁进程的套接字路径
+func VaultSocketPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vault.sock"), nil
+}
+
+// RunVaultAgent 运行后台解锁进程，在 ttl 时间内向本机的 goss 进程提供密钥
+// 该函数会阻塞，直到超时或收到 lock 请求
+func RunVaultAgent(key []byte, ttl time.Duration) error {
+	path, err := VaultSocketPath()
+	if err != nil {
+		return err
+	}
+
+	listener, err := daemon.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	deadline := time.Now().Add(ttl)
+	timer := time.AfterFunc(ttl, func() { listener.Close() })
+	defer timer.Stop()
+
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// 超时或被 lock 请求关闭
+			return nil
+		}
+
+		if handleAgentConn(conn, encodedKey, deadline) {
+			return nil
+		}
+	}
+}
+
+// handleAgentConn 处理一个请求，返回 true 表示需要退出
+func handleAgentConn(conn net.Conn, encodedKey string, deadline time.Time) bool {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.TrimSpace(line) {
+	case agentCmdGet:
+		fmt.Fprintln(conn, encodedKey)
+	case agentCmdStatus:
+		fmt.Fprintln(conn, int(time.Until(deadline).Seconds()))
+	case agentCmdLock:
+		fmt.Fprintln(conn, "ok")
+		return true
+	}
+	return false
+}
+
+// agentRequest 向后台解锁进程发送请求并返回响应
+func agentRequest(command string) (string, error) {
+	path, err := VaultSocketPath()
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := daemon.Dial(path)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", err
+	}
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// agentKey 从后台解锁进程获取密钥
+func agentKey() ([]byte, error) {
+	resp, err := agentRequest(agentCmdGet)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp)
+}
+
+// LockVaultAgent 通知后台解锁进程清除密钥并退出
+// 返回 false 表示没有正在运行的解锁进程
+func LockVaultAgent() bool {
+	_, err := agentRequest(agentCmdLock)
+	return err == nil
+}
+
+// VaultAgentStatus 返回后台解锁进程的剩余有效时间
+// 返回 false 表示没有正在运行的解锁进程
+func VaultAgentStatus() (time.Duration, bool) {
+	resp, err := agentRequest(agentCmdStatus)
+	if err != nil {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(resp)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,461 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"goSSH/internal/config"
+	"goSSH/internal/daemon"
+	"goSSH/internal/ssh"
+	"goSSH/models"
+)
+
+// manager 管理后台进程中运行的所有隧道，同一服务器的隧道共用一个SSH连接
+type manager struct {
+	mu       sync.Mutex
+	sessions map[string]*session // 按服务器名称
+	failed   map[Target]Status   // 停止重连的隧道，保留到再次启动或停止
+	logger   *log.Logger
+}
+
+// session 表示到一个服务器的SSH连接及其上运行的隧道
+type session struct {
+	server    string
+	client    *ssh.Client
+	tunnels   map[string]*tunnel // 按隧道名称
+	connected bool
+	closed    bool
+	cancel    context.CancelFunc // 停止重连
+	err       string             // 最近一次重连失败的原因
+	since     time.Time          // 连接建立或断开的时间
+}
+
+// tunnel 表示一个运行中的隧道
+type tunnel struct {
+	config    models.Tunnel
+	spec      ssh.ForwardSpec
+	stats     *ssh.ForwardStats // 跨重连累计的流量统计
+	forwarder *ssh.Forwarder    // 连接断开期间为 nil
+	err       string            // 重连后启动失败的原因
+}
+
+// RunDaemon 运行隧道后台进程，在本地套接字上接受 goss tunnel 命令的请求
+// 该函数会阻塞，直到所有隧道都已停止
+func RunDaemon() error {
+	path, err := SocketPath()
+	if err != nil {
+		return err
+	}
+
+	logPath, err := LogPath()
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %v", err)
+	}
+	defer logFile.Close()
+
+	listener, err := daemon.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	m := &manager{
+		sessions: make(map[string]*session),
+		failed:   make(map[Target]Status),
+		logger:   log.New(logFile, "", log.LstdFlags),
+	}
+	m.logger.Printf("隧道后台进程已启动 (pid %d)", os.Getpid())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return nil
+		}
+
+		go func() {
+			if m.handle(conn) {
+				m.logger.Printf("所有隧道均已停止，后台进程退出")
+				listener.Close()
+			}
+		}()
+	}
+}
+
+// handle 处理一个请求，返回 true 表示已没有运行中的隧道，后台进程需要退出
+func (m *manager) handle(conn net.Conn) bool {
+	defer conn.Close()
+
+	var req request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return false
+	}
+
+	var resp response
+	switch req.Command {
+	case cmdUp:
+		for _, target := range req.Targets {
+			resp.Results = append(resp.Results, m.up(target))
+		}
+	case cmdDown:
+		resp.Results = m.down(req.Targets)
+	case cmdStatus:
+		resp.Tunnels = m.status()
+	default:
+		resp.Error = fmt.Sprintf("未知的请求: %s", req.Command)
+	}
+
+	json.NewEncoder(conn).Encode(resp)
+	return req.Command != cmdStatus && m.empty()
+}
+
+// empty 判断是否已没有运行中或停止重连的隧道
+func (m *manager) empty() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions) == 0 && len(m.failed) == 0
+}
+
+// up 启动一个隧道，服务器尚未连接时先建立连接
+func (m *manager) up(target Target) Result {
+	result := Result{Target: target}
+
+	server, t, err := loadTunnel(target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	spec, err := Spec(*t)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	m.mu.Lock()
+	s := m.sessions[target.Server]
+	if s != nil {
+		if _, ok := s.tunnels[target.Tunnel]; ok {
+			m.mu.Unlock()
+			result.Error = "隧道已在运行"
+			return result
+		}
+	}
+	m.mu.Unlock()
+
+	// 连接服务器时不持有锁，避免阻塞状态查询
+	var client *ssh.Client
+	if s == nil {
+		client = ssh.NewClient(server)
+		client.DisableMaster()
+		if err := client.Connect(); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing := m.sessions[target.Server]; existing != nil {
+		// 连接期间已有其他请求建立了连接，使用已有的连接
+		if client != nil {
+			client.Close()
+		}
+		s = existing
+	} else if client == nil {
+		// 连接期间原有的连接已被关闭
+		result.Error = "连接已关闭，请重试"
+		return result
+	} else {
+		ctx, cancel := context.WithCancel(context.Background())
+		s = &session{
+			server:    target.Server,
+			client:    client,
+			tunnels:   make(map[string]*tunnel),
+			connected: true,
+			cancel:    cancel,
+			since:     time.Now(),
+		}
+		m.sessions[target.Server] = s
+		m.logger.Printf("[%s] 已连接", s.server)
+		go m.watch(ctx, s)
+	}
+
+	if _, ok := s.tunnels[target.Tunnel]; ok {
+		result.Error = "隧道已在运行"
+		return result
+	}
+
+	tun := &tunnel{config: *t, spec: spec, stats: &ssh.ForwardStats{}}
+	if s.connected {
+		if err := m.start(s, tun); err != nil {
+			m.closeIfEmpty(s)
+			result.Error = err.Error()
+			return result
+		}
+	}
+	s.tunnels[target.Tunnel] = tun
+	delete(m.failed, target)
+	return result
+}
+
+// loadTunnel 从配置文件中读取服务器和隧道配置
+func loadTunnel(target Target) (*models.Server, *models.Tunnel, error) {
+	manager, err := config.NewManager()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	server, err := manager.GetServer(target.Server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range server.Tunnels {
+		if server.Tunnels[i].Name == target.Tunnel {
+			return server, &server.Tunnels[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("服务器 '%s' 没有名为 '%s' 的隧道", target.Server, target.Tunnel)
+}
+
+// start 在会话的SSH连接上启动隧道，调用方需持有锁
+func (m *manager) start(s *session, tun *tunnel) error {
+	var forwarder *ssh.Forwarder
+	var err error
+	switch tun.config.Type {
+	case TypeLocal:
+		forwarder, err = ssh.ForwardLocal(s.client, tun.spec)
+	case TypeRemote:
+		forwarder, err = ssh.ForwardRemote(s.client, tun.spec)
+	case TypeSocks:
+		forwarder, err = ssh.ForwardSOCKS(s.client, tun.spec, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	prefix := fmt.Sprintf("[%s/%s] ", s.server, tun.config.Name)
+	forwarder.Stats = tun.stats
+	forwarder.Logf = func(format string, args ...any) {
+		m.logger.Printf(prefix+format, args...)
+	}
+	tun.forwarder = forwarder
+
+	m.logger.Printf("%s已启动 %s", prefix, forwarder.Spec)
+	go forwarder.Serve()
+	return nil
+}
+
+// watch 等待SSH连接断开，断开后停止该连接上的转发，按指数退避重连并重新启动所有隧道
+func (m *manager) watch(ctx context.Context, s *session) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.client.Done():
+		}
+
+		m.mu.Lock()
+		if s.closed {
+			m.mu.Unlock()
+			return
+		}
+		m.logger.Printf("[%s] 连接已断开: %v，开始重连", s.server, s.client.Err())
+		for _, tun := range s.tunnels {
+			if tun.forwarder != nil {
+				tun.forwarder.Close()
+				tun.forwarder = nil
+			}
+		}
+		s.connected = false
+		s.since = time.Now()
+		m.mu.Unlock()
+
+		err := s.client.ReconnectWithBackoff(ctx, func(attempt int, delay time.Duration, err error) {
+			m.setError(s, fmt.Errorf("%v（%s 后重试）", err, delay.Round(time.Second)))
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				// 认证失败、主机密钥校验失败等无法通过重试解决的错误
+				m.fail(s, err)
+			}
+			return
+		}
+
+		m.mu.Lock()
+		if s.closed {
+			// 重连期间所有隧道已停止
+			m.mu.Unlock()
+			s.client.Close()
+			return
+		}
+
+		s.connected = true
+		s.err = ""
+		s.since = time.Now()
+		m.logger.Printf("[%s] 已重新连接", s.server)
+		for _, tun := range s.tunnels {
+			tun.err = ""
+			if err := m.start(s, tun); err != nil {
+				tun.err = err.Error()
+				m.logger.Printf("[%s/%s] 启动失败: %v", s.server, tun.config.Name, err)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// setError 记录重连失败的原因
+func (m *manager) setError(s *session, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.err = err.Error()
+	m.logger.Printf("[%s] 重连失败: %v", s.server, err)
+}
+
+// fail 停止重连后将会话上的隧道记为失败并关闭会话，失败的隧道在状态中保留到再次启动或停止
+func (m *manager) fail(s *session, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	now := time.Now()
+	for _, tun := range s.tunnels {
+		st := tunnelStatus(s, tun)
+		st.State = StateFailed
+		st.Error = err.Error()
+		st.Since = now
+		m.failed[st.Target] = st
+	}
+	m.logger.Printf("[%s] 停止重连: %v", s.server, err)
+
+	s.tunnels = nil
+	m.closeIfEmpty(s)
+}
+
+// down 停止指定的隧道，targets 为空时停止所有隧道
+func (m *manager) down(targets []Target) []Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(targets) == 0 {
+		for _, s := range m.sessions {
+			for name := range s.tunnels {
+				targets = append(targets, Target{Server: s.server, Tunnel: name})
+			}
+		}
+		for target := range m.failed {
+			targets = append(targets, target)
+		}
+	}
+
+	var results []Result
+	for _, target := range targets {
+		result := Result{Target: target}
+		if _, ok := m.failed[target]; ok {
+			delete(m.failed, target)
+			m.logger.Printf("[%s] 已停止", target)
+			results = append(results, result)
+			continue
+		}
+
+		s := m.sessions[target.Server]
+		var tun *tunnel
+		if s != nil {
+			tun = s.tunnels[target.Tunnel]
+		}
+		if tun == nil {
+			result.Error = "隧道未运行"
+			results = append(results, result)
+			continue
+		}
+
+		if tun.forwarder != nil {
+			tun.forwarder.Close()
+		}
+		delete(s.tunnels, target.Tunnel)
+		m.logger.Printf("[%s] 已停止", target)
+		m.closeIfEmpty(s)
+		results = append(results, result)
+	}
+	return results
+}
+
+// closeIfEmpty 会话上没有隧道时关闭SSH连接，调用方需持有锁
+func (m *manager) closeIfEmpty(s *session) {
+	if len(s.tunnels) > 0 {
+		return
+	}
+
+	s.closed = true
+	s.cancel()
+	s.client.Close()
+	delete(m.sessions, s.server)
+	m.logger.Printf("[%s] 连接已关闭", s.server)
+}
+
+// tunnelStatus 返回会话上一个隧道的状态，调用方需持有锁
+func tunnelStatus(s *session, tun *tunnel) Status {
+	st := Status{
+		Target:        Target{Server: s.server, Tunnel: tun.config.Name},
+		Type:          tun.config.Type,
+		Spec:          tun.spec.String(),
+		State:         StateRunning,
+		Since:         s.since,
+		Connections:   tun.stats.Connections.Load(),
+		Active:        tun.stats.Active.Load(),
+		BytesSent:     tun.stats.BytesSent.Load(),
+		BytesReceived: tun.stats.BytesReceived.Load(),
+	}
+	switch {
+	case tun.err != "":
+		st.State = StateFailed
+		st.Error = tun.err
+	case tun.forwarder == nil:
+		st.State = StateReconnecting
+		st.Error = s.err
+	default:
+		// 监听端口为 0 时显示实际分配的端口
+		st.Spec = tun.forwarder.Spec.String()
+	}
+	return st
+}
+
+// status 返回所有隧道的状态，按服务器和隧道名称排序
+func (m *manager) status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []Status
+	for _, s := range m.sessions {
+		for _, tun := range s.tunnels {
+			list = append(list, tunnelStatus(s, tun))
+		}
+	}
+	for _, st := range m.failed {
+		list = append(list, st)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Server != list[j].Server {
+			return list[i].Server < list[j].Server
+		}
+		return list[i].Tunnel < list[j].Tunnel
+	})
+	return list
+}
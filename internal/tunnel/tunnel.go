@@ -0,0 +1,220 @@
+package tunnel
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"goSSH/internal/daemon"
+	"goSSH/internal/ssh"
+	"goSSH/internal/storage"
+	"goSSH/models"
+)
+
+// 隧道类型
+const (
+	TypeLocal  = "local"  // 本地端口转发（-L）
+	TypeRemote = "remote" // 远程端口转发（-R）
+	TypeSocks  = "socks"  // SOCKS5 动态转发（-D）
+)
+
+// 隧道状态
+const (
+	StateRunning      = "running"      // 正在运行
+	StateReconnecting = "reconnecting" // 连接已断开，正在重连
+	StateFailed       = "failed"       // 重连后启动失败（如本地端口被占用），或因认证失败等原因停止重连
+	StateStopped      = "stopped"      // 未运行
+)
+
+// 后台进程支持的请求
+const (
+	cmdUp     = "up"     // 启动隧道
+	cmdDown   = "down"   // 停止隧道
+	cmdStatus = "status" // 查询状态
+)
+
+// Target 表示一个隧道
+type Target struct {
+	Server string `json:"server"`
+	Tunnel string `json:"tunnel"`
+}
+
+// String 返回 server/tunnel 形式的名称
+func (t Target) String() string {
+	return t.Server + "/" + t.Tunnel
+}
+
+// Result 表示对一个隧道执行操作的结果
+type Result struct {
+	Target
+	Error string `json:"error,omitempty"`
+}
+
+// Status 表示一个运行中隧道的状态
+type Status struct {
+	Target
+	Type          string    `json:"type"`
+	Spec          string    `json:"spec"`
+	State         string    `json:"state"`
+	Error         string    `json:"error,omitempty"` // 最近一次重连失败的原因
+	Since         time.Time `json:"since"`           // 当前状态的开始时间
+	Connections   int64     `json:"connections"`
+	Active        int64     `json:"active"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+}
+
+// request 发送给后台进程的请求
+type request struct {
+	Command string   `json:"command"`
+	Targets []Target `json:"targets,omitempty"`
+}
+
+// response 后台进程的响应
+type response struct {
+	Error   string   `json:"error,omitempty"`
+	Results []Result `json:"results,omitempty"`
+	Tunnels []Status `json:"tunnels,omitempty"`
+}
+
+// Spec 将隧道配置转换为端口转发规则
+func Spec(t models.Tunnel) (ssh.ForwardSpec, error) {
+	if t.Local == "" {
+		return ssh.ForwardSpec{}, fmt.Errorf("隧道 '%s' 缺少 local 配置", t.Name)
+	}
+
+	switch t.Type {
+	case TypeLocal:
+		return ssh.ParseForwardSpec(t.Local+":"+t.Remote, "127.0.0.1")
+	case TypeRemote:
+		return ssh.ParseForwardSpec(t.Remote+":"+t.Local, "localhost")
+	case TypeSocks:
+		return ssh.ParseSocksSpec(t.Local, "127.0.0.1")
+	default:
+		return ssh.ForwardSpec{}, fmt.Errorf("隧道 '%s' 的类型 '%s' 无效，可用: local、remote、socks", t.Name, t.Type)
+	}
+}
+
+// SocketPath 返回隧道后台进程的套接字路径
+func SocketPath() (string, error) {
+	dir, err := storage.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tunnel.sock"), nil
+}
+
+// LogPath 返回隧道后台进程的日志文件路径
+func LogPath() (string, error) {
+	dir, err := storage.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tunnel.log"), nil
+}
+
+// Up 启动隧道，后台进程未运行时自动启动
+func Up(targets []Target) ([]Result, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if !daemon.IsRunning(path) {
+		if err := startDaemon(path); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := send(request{Command: cmdUp, Targets: targets}, 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// Down 停止隧道，targets 为空时停止所有隧道
+// 返回 false 表示后台进程未运行
+func Down(targets []Target) ([]Result, bool, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, false, err
+	}
+	if !daemon.IsRunning(path) {
+		return nil, false, nil
+	}
+
+	resp, err := send(request{Command: cmdDown, Targets: targets}, 30*time.Second)
+	if err != nil {
+		return nil, true, err
+	}
+	return resp.Results, true, nil
+}
+
+// Running 查询运行中隧道的状态
+// 返回 false 表示后台进程未运行
+func Running() ([]Status, bool, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, false, err
+	}
+	if !daemon.IsRunning(path) {
+		return nil, false, nil
+	}
+
+	resp, err := send(request{Command: cmdStatus}, 10*time.Second)
+	if err != nil {
+		return nil, true, err
+	}
+	return resp.Tunnels, true, nil
+}
+
+// startDaemon 启动隧道后台进程，已解锁的主密码密钥通过标准输入传递
+func startDaemon(path string) error {
+	st, err := storage.NewStorage()
+	if err != nil {
+		return err
+	}
+
+	key, err := st.UnlockedKey()
+	if err != nil {
+		return err
+	}
+
+	input := []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err := daemon.Start(input, "tunnel", "daemon"); err != nil {
+		return err
+	}
+	return daemon.WaitRunning(path, 5*time.Second)
+}
+
+// send 向后台进程发送请求并等待响应
+func send(req request, timeout time.Duration) (*response, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := daemon.Dial(path)
+	if err != nil {
+		return nil, fmt.Errorf("连接隧道后台进程失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+
+	var resp response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	return &resp, nil
+}
@@ -6,11 +6,91 @@ type Server struct {
 	Host     string `json:"host"`     // IP地址或主机名
 	Port     int    `json:"port"`     // SSH端口，默认22
 	Username string `json:"username"` // 用户名
-	Password string `json:"password"` // 密码（明文存储）
+	Password string `json:"password"` // 密码（启用主密码模式后加密存储）
+
+	PasswordRef string `json:"password_ref,omitempty"` // 密码引用（可选），如 env:PROD_PW、keyring:gossh/prod-db、cmd:pass show prod/db
+
+	Tags []string `json:"tags,omitempty"` // 标签（可选），如 web、env=prod；不含 = 的标签同时作为分组，可以通过 @web 选择
+
+	IdentityFile    string `json:"identity_file,omitempty"`    // 私钥文件路径（可选，优先于密码认证）
+	CertificateFile string `json:"certificate_file,omitempty"` // 用户证书路径（可选），未配置时自动使用 <私钥>-cert.pub
+	Passphrase      string `json:"passphrase,omitempty"`       // 私钥口令（可选，留空则在需要时询问）
+	ForwardAgent    bool   `json:"forward_agent,omitempty"`    // 是否将本地 ssh-agent 转发到远程服务器
+
+	TOTPSecret    string `json:"totp_secret,omitempty"`     // TOTP 种子（可选，启用主密码模式后加密存储），用于自动回答一次性密码
+	TOTPSecretRef string `json:"totp_secret_ref,omitempty"` // TOTP 种子引用（可选），格式同 password_ref
+
+	Jump []string `json:"jump,omitempty"` // 跳板机（已配置的服务器名称，按连接顺序）
+
+	Proxy            string `json:"proxy,omitempty"`              // 出站代理，如 socks5://proxy:1080、http://user@proxy:3128，none 表示不使用全局代理
+	ProxyPassword    string `json:"proxy_password,omitempty"`     // 代理密码（可选，启用主密码模式后加密存储）
+	ProxyPasswordRef string `json:"proxy_password_ref,omitempty"` // 代理密码引用（可选），格式同 password_ref
+
+	StrictHostKeyChecking string `json:"strict_host_key_checking,omitempty"` // 主机密钥校验策略: yes/ask/no，默认 ask
+	UseSystemKnownHosts   *bool  `json:"use_system_known_hosts,omitempty"`   // 是否同时读取 ~/.ssh/known_hosts，未配置时使用全局设置
+	HostCAFile            string `json:"host_ca_file,omitempty"`             // 受信任的主机 CA 公钥文件（可选），接受由其签发的主机证书
+
+	KeepaliveInterval int `json:"keepalive_interval,omitempty"`  // 保活间隔（秒），默认 30，小于 0 表示关闭
+	KeepaliveCountMax int `json:"keepalive_count_max,omitempty"` // 连续未响应多少次后断开连接，默认 3
+
+	ControlMaster  *bool `json:"control_master,omitempty"`  // 是否通过主连接进程复用连接，未配置时使用全局设置
+	ControlPersist int   `json:"control_persist,omitempty"` // 主连接空闲多少秒后关闭，默认 600
+
+	// 算法配置（可选），未配置时使用默认值；以 + 开头表示在默认列表上追加，以 - 开头表示从默认列表中移除
+	Ciphers           []string `json:"ciphers,omitempty"`             // 加密算法
+	KeyExchanges      []string `json:"key_exchanges,omitempty"`       // 密钥交换算法
+	MACs              []string `json:"macs,omitempty"`                // MAC 算法
+	HostKeyAlgorithms []string `json:"host_key_algorithms,omitempty"` // 主机密钥算法
+
+	Tunnels []Tunnel `json:"tunnels,omitempty"` // 保存的隧道配置
+}
+
+// Enabled 返回可选开关的值，未配置时为 false
+func Enabled(value *bool) bool {
+	return value != nil && *value
+}
+
+// Tunnel 表示一个保存在服务器配置中的隧道（端口转发）
+type Tunnel struct {
+	Name      string `json:"name"`                // 隧道名称，同一服务器内唯一
+	Type      string `json:"type"`                // 类型: local（-L）、remote（-R）、socks（-D）
+	Local     string `json:"local"`               // local/socks: 本地监听地址 [bind_address:]port；remote: 本地目标地址 host:port
+	Remote    string `json:"remote,omitempty"`    // local: 远程目标地址 host:port；remote: 远程监听地址 [bind_address:]port
+	Autostart bool   `json:"autostart,omitempty"` // 执行 goss tunnel up 且未指定隧道时是否启动
+}
+
+// Settings 表示全局设置，作为各服务器未单独配置时的默认值
+type Settings struct {
+	StrictHostKeyChecking string   `json:"strict_host_key_checking,omitempty"` // 默认主机密钥校验策略
+	UseSystemKnownHosts   bool     `json:"use_system_known_hosts,omitempty"`   // 默认是否读取 ~/.ssh/known_hosts
+	HostCAFile            string   `json:"host_ca_file,omitempty"`             // 默认受信任的主机 CA 公钥文件
+	Proxy                 string   `json:"proxy,omitempty"`                    // 默认出站代理
+	ProxyPasswordRef      string   `json:"proxy_password_ref,omitempty"`       // 默认代理的密码引用
+	KeepaliveInterval     int      `json:"keepalive_interval,omitempty"`       // 默认保活间隔（秒）
+	KeepaliveCountMax     int      `json:"keepalive_count_max,omitempty"`      // 默认连续未响应次数上限
+	ControlMaster         bool     `json:"control_master,omitempty"`           // 默认是否复用连接
+	ControlPersist        int      `json:"control_persist,omitempty"`          // 默认主连接空闲超时（秒）
+	Ciphers               []string `json:"ciphers,omitempty"`                  // 默认加密算法
+	KeyExchanges          []string `json:"key_exchanges,omitempty"`            // 默认密钥交换算法
+	MACs                  []string `json:"macs,omitempty"`                     // 默认 MAC 算法
+	HostKeyAlgorithms     []string `json:"host_key_algorithms,omitempty"`      // 默认主机密钥算法
+}
+
+// Vault 表示主密码模式的加密参数
+// 启用后，配置文件中的密码等敏感字段使用由主密码派生的密钥加密存储
+type Vault struct {
+	KDF   string `json:"kdf"`   // 密钥派生算法，目前为 scrypt
+	Salt  string `json:"salt"`  // 派生盐值（base64）
+	N     int    `json:"n"`     // scrypt CPU/内存开销参数
+	R     int    `json:"r"`     // scrypt 块大小参数
+	P     int    `json:"p"`     // scrypt 并行参数
+	Check string `json:"check"` // 加密后的校验值，用于验证主密码是否正确
 }
 
 // ServerConfig 表示服务器配置文件结构
 type ServerConfig struct {
-	Servers []Server `json:"servers"` // 服务器列表
+	Vault    *Vault   `json:"vault,omitempty"`   // 加密参数（未启用主密码模式时为空）
+	Settings Settings `json:"settings,omitzero"` // 全局设置
+	Servers  []Server `json:"servers"`           // 服务器列表
 }
 